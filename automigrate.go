@@ -0,0 +1,157 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultAutoMigrateConcurrency 是 AutoMigrateOptions.Concurrency 为 0 时同时迁移的数据库数量
+const DefaultAutoMigrateConcurrency = 8
+
+// AutoMigrateOptions AutoMigrateGroupWithOptions 的选项
+type AutoMigrateOptions struct {
+	// Concurrency 同时迁移的数据库数量上限，为 0 时使用 DefaultAutoMigrateConcurrency，为 1 时按连接名依次迁移
+	Concurrency int
+	// DryRun 只记录 GORM 将要执行的 DDL 而不执行，查询表结构的语句仍会执行
+	DryRun bool
+}
+
+// AutoMigrateResult 单个数据库的迁移结果
+type AutoMigrateResult struct {
+	Name       string        // Name 连接名
+	Statements []string      // Statements DryRun 时记录的 DDL，按执行顺序排列
+	Duration   time.Duration // Duration 迁移耗时
+	Err        error         // Err 获取连接或迁移失败时的错误
+}
+
+// AutoMigrateReport 所有数据库的迁移结果
+type AutoMigrateReport struct {
+	Results []AutoMigrateResult // Results 按连接名排序
+}
+
+// Failed 返回迁移失败的结果
+func (r AutoMigrateReport) Failed() []AutoMigrateResult {
+	var failed []AutoMigrateResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err 返回合并后的错误，每个错误以失败的连接名开头；全部成功时返回 nil
+func (r AutoMigrateReport) Err() error {
+	var errs []error
+	for _, result := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", result.Name, result.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// AutoMigrateGroup 使用默认选项对 group 中的连接并发执行 AutoMigrate，参见 AutoMigrateGroupWithOptions。
+func AutoMigrateGroup(ctx context.Context, group Group, names []string, models ...any) (AutoMigrateReport, error) {
+	return AutoMigrateGroupWithOptions(ctx, group, names, AutoMigrateOptions{}, models...)
+}
+
+// AutoMigrateGroupWithOptions 对 group 中名为 names 的连接（为空时为所有连接）并发执行 db.AutoMigrate(models...)，
+// 返回每个数据库的结果与合并后的错误。
+//
+// 单个数据库失败不影响其他数据库，同时迁移的数量受 Concurrency 限制，
+// 因此可以在启动时对 BatchMustRegisterToDB 注册的大量租户库调用；尚未打开的连接会被打开。
+//
+// DryRun 为 true 时，GORM 生成的 DDL 被记录到 AutoMigrateResult.Statements 而不执行，
+// 查询表结构的语句仍然执行，因此记录的是针对数据库当前结构需要执行的语句。
+// 配置了从库时 DDL 同样不会执行。
+func AutoMigrateGroupWithOptions(ctx context.Context, group Group, names []string, opts AutoMigrateOptions, models ...any) (AutoMigrateReport, error) {
+	names = slices.Clone(names)
+	if len(names) == 0 {
+		names = group.List()
+	}
+	slices.Sort(names)
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultAutoMigrateConcurrency
+	}
+
+	results := make([]AutoMigrateResult, len(names))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		results[i].Name = name
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(result *AutoMigrateResult) {
+			defer func() { <-sem; wg.Done() }()
+			start := time.Now()
+			result.Statements, result.Err = autoMigrate(ctx, group, result.Name, opts.DryRun, models)
+			result.Duration = time.Since(start)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	report := AutoMigrateReport{Results: results}
+	return report, report.Err()
+}
+
+// autoMigrate 对单个连接执行 AutoMigrate，dryRun 时返回记录的 DDL
+func autoMigrate(ctx context.Context, group Group, name string, dryRun bool, models []any) ([]string, error) {
+	db, err := group.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	db = db.WithContext(ctx)
+	if !dryRun {
+		return nil, db.AutoMigrate(models...)
+	}
+
+	recorder := &ddlRecorder{ConnPool: db.Statement.ConnPool, dialector: db.Dialector}
+	// 记录器对 GORM 表现为事务，嵌套事务不创建保存点，避免把 SAVEPOINT 记录为 DDL
+	tx := db.Session(&gorm.Session{DisableNestedTransaction: true})
+	tx.Statement.ConnPool = recorder
+	if err := tx.AutoMigrate(models...); err != nil {
+		return recorder.statements, err
+	}
+	return recorder.statements, nil
+}
+
+// ddlRecorder 包装连接池，记录 Exec 执行的语句而不执行，查询照常执行。
+//
+// 实现 gorm.TxCommitter 使 GORM 与读写分离插件把它当作事务，
+// 从而不会把语句切换到其他连接池上执行。
+type ddlRecorder struct {
+	gorm.ConnPool
+	dialector gorm.Dialector
+
+	mu         sync.Mutex
+	statements []string
+}
+
+// ExecContext 记录语句，返回影响 0 行的结果
+func (r *ddlRecorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if len(args) > 0 {
+		query = r.dialector.Explain(query, args...)
+	}
+	r.mu.Lock()
+	r.statements = append(r.statements, query)
+	r.mu.Unlock()
+	return driver.RowsAffected(0), nil
+}
+
+// Commit 实现 gorm.TxCommitter，不做任何处理
+func (r *ddlRecorder) Commit() error {
+	return nil
+}
+
+// Rollback 实现 gorm.TxCommitter，不做任何处理
+func (r *ddlRecorder) Rollback() error {
+	return nil
+}
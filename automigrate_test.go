@@ -0,0 +1,88 @@
+package mgorm
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// autoMigrateUser 用于 AutoMigrate 的模型
+type autoMigrateUser struct {
+	ID   uint
+	Name string
+}
+
+// TestAutoMigrateGroup 测试并发迁移组内所有数据库，单个数据库失败不影响其他数据库
+func TestAutoMigrateGroup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "source", DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, "source.db")})
+	toNameDBMap := map[string]string{}
+	for _, name := range []string{"tenant_a", "tenant_b", "tenant_c"} {
+		toNameDBMap[name] = filepath.Join(dir, name+".db")
+	}
+	BatchMustRegisterToDB(ctx, group, "source", toNameDBMap)
+	// 打开时失败的连接
+	group.Register(ctx, "broken", DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, "missing", "x.db")})
+
+	report, err := AutoMigrateGroupWithOptions(ctx, group, nil, AutoMigrateOptions{Concurrency: 2}, &autoMigrateUser{})
+	if err == nil || !strings.HasPrefix(err.Error(), "broken: ") {
+		t.Fatalf("错误应以失败的连接名开头，实际为: %v", err)
+	}
+	if len(report.Results) != 5 || report.Results[0].Name != "broken" {
+		t.Fatalf("结果应按连接名排序: %+v", report.Results)
+	}
+	if failed := report.Failed(); len(failed) != 1 {
+		t.Errorf("Failed() = %+v", failed)
+	}
+	for name := range toNameDBMap {
+		if !group.MustGet(ctx, name).Migrator().HasTable(&autoMigrateUser{}) {
+			t.Errorf("%s 中应创建表", name)
+		}
+	}
+
+	// 只迁移指定连接
+	report, err = AutoMigrateGroup(ctx, group, []string{"source"}, &autoMigrateUser{})
+	if err != nil || len(report.Results) != 1 {
+		t.Fatalf("AutoMigrateGroup() = %+v, %v", report, err)
+	}
+}
+
+// TestAutoMigrateGroup_DryRun 测试 DryRun 只记录 DDL，配置了从库时同样不执行
+func TestAutoMigrateGroup_DryRun(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "plain", DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, "plain.db")})
+	group.Register(ctx, "replicated", DBConfig{
+		DriverType: "sqlite",
+		DBName:     filepath.Join(dir, "primary.db"),
+		Replicas:   []ReplicaConfig{{DBName: filepath.Join(dir, "replica.db")}},
+	})
+
+	report, err := AutoMigrateGroupWithOptions(ctx, group, nil, AutoMigrateOptions{DryRun: true}, &autoMigrateUser{})
+	if err != nil {
+		t.Fatalf("AutoMigrateGroupWithOptions() 失败: %v", err)
+	}
+	for _, result := range report.Results {
+		ddl := strings.Join(result.Statements, "\n")
+		if !strings.Contains(ddl, "CREATE TABLE `auto_migrate_users`") {
+			t.Errorf("%s 应记录建表语句，实际为: %q", result.Name, ddl)
+		}
+		db := group.MustGet(ctx, result.Name)
+		if db.Migrator().HasTable(&autoMigrateUser{}) {
+			t.Errorf("%s 中 DryRun 不应建表", result.Name)
+		}
+	}
+
+	// 表已存在时没有需要执行的 DDL
+	group.MustGet(ctx, "plain").AutoMigrate(&autoMigrateUser{})
+	report, _ = AutoMigrateGroupWithOptions(ctx, group, []string{"plain"}, AutoMigrateOptions{DryRun: true}, &autoMigrateUser{})
+	if statements := report.Results[0].Statements; len(statements) != 0 {
+		t.Errorf("表结构一致时不应记录 DDL，实际为: %q", statements)
+	}
+}
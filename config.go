@@ -1,70 +1,391 @@
 package mgorm
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // DBConfig 数据库配置
+//
+// 每个可序列化字段都同时带有 yaml、json、toml 与 mapstructure 标签，且 key 完全一致，
+// 因此同一份配置可以在 YAML、JSON、TOML 以及 viper 之间互相转换。
+// time.Duration 类型的字段在所有格式中都支持 "30m"、"1h" 这样的字符串写法。
 type DBConfig struct {
-	Name            string         `yaml:"name" mapstructure:"name"`               // 数据库描述名称（可选，用于日志记录等，不作为连接标识）
-	DSN             string         `yaml:"dsn" mapstructure:"dsn"`                 // 数据源名称（连接字符串）
-	DriverType      string         `yaml:"driver_type" mapstructure:"driver_type"` // 驱动类型（如 mysql, postgres 等）
-	Host            string         `json:"host" mapstructure:"host"`
-	Port            int            `yaml:"port" mapstructure:"port"`
-	User            string         `json:"user" mapstructure:"user"`
-	Password        string         `yaml:"password" mapstructure:"password"`
-	DBName          string         `json:"db_name" mapstructure:"db_name"`
-	Charset         string         `json:"charset" mapstructure:"charset"`
-	MaxIdleConns    int            `yaml:"max_idle_conns" mapstructure:"max_idle_conns"`       // 最大空闲连接数
-	MaxOpenConns    int            `yaml:"max_open_conns" mapstructure:"max_open_conns"`       // 最大打开连接数
-	ConnMaxLifetime time.Duration  `yaml:"conn_max_lifetime" mapstructure:"conn_max_lifetime"` // 连接最大生存时间
-	Dialector       gorm.Dialector `yaml:"-" mapstructure:"-"`                                 // 自定义方言驱动（可选，如果设置则忽略 DriverType 和 DSN）
+	Name            string         `yaml:"name" json:"name" toml:"name" mapstructure:"name"`                                                         // 数据库描述名称（可选，用于日志记录等，不作为连接标识）
+	DSN             string         `yaml:"dsn" json:"dsn" toml:"dsn" mapstructure:"dsn"`                                                             // 数据源名称（连接字符串）
+	DriverType      string         `yaml:"driver_type" json:"driver_type" toml:"driver_type" mapstructure:"driver_type"`                             // 驱动类型（如 mysql, postgres 等）
+	Host            string         `yaml:"host" json:"host" toml:"host" mapstructure:"host"`                                                         // 数据库主机地址
+	Port            int            `yaml:"port" json:"port" toml:"port" mapstructure:"port"`                                                         // 数据库端口
+	User            string         `yaml:"user" json:"user" toml:"user" mapstructure:"user"`                                                         // 数据库用户名
+	Password        string         `yaml:"password" json:"password" toml:"password" mapstructure:"password"`                                         // 数据库密码
+	DBName          string         `yaml:"db_name" json:"db_name" toml:"db_name" mapstructure:"db_name"`                                             // 数据库名称
+	Charset         string         `yaml:"charset" json:"charset" toml:"charset" mapstructure:"charset"`                                             // 字符集（MySQL 默认 utf8mb4）
+	SearchPath      string         `yaml:"search_path" json:"search_path" toml:"search_path" mapstructure:"search_path"`                             // PostgreSQL 的 search_path（可选，自动生成 DSN 时写入）
+	MaxIdleConns    int            `yaml:"max_idle_conns" json:"max_idle_conns" toml:"max_idle_conns" mapstructure:"max_idle_conns"`                 // 最大空闲连接数（0 使用 database/sql 默认值 2，负数表示不保留空闲连接）
+	MaxOpenConns    int            `yaml:"max_open_conns" json:"max_open_conns" toml:"max_open_conns" mapstructure:"max_open_conns"`                 // 最大打开连接数（0 使用 database/sql 默认值，负数表示不限制）
+	ConnMaxLifetime time.Duration  `yaml:"conn_max_lifetime" json:"conn_max_lifetime" toml:"conn_max_lifetime" mapstructure:"conn_max_lifetime"`     // 连接最大生存时间（0 或负数表示不限制）
+	ConnMaxIdleTime time.Duration  `yaml:"conn_max_idle_time" json:"conn_max_idle_time" toml:"conn_max_idle_time" mapstructure:"conn_max_idle_time"` // 连接最大空闲时间（0 或负数表示不限制，代理会断开空闲 TCP 连接时应小于代理的超时）
+	Dialector       gorm.Dialector `yaml:"-" json:"-" toml:"-" mapstructure:"-"`                                                                     // 自定义方言驱动（可选，如果设置则忽略 DriverType 和 DSN）
+
+	// GORM 配置：以下可序列化字段为 true 或非空时覆盖 GormConfig 中的对应选项，参见 BuildGormConfig
+
+	// PrepareStmt 缓存预编译语句
+	PrepareStmt bool `yaml:"prepare_stmt" json:"prepare_stmt" toml:"prepare_stmt" mapstructure:"prepare_stmt"`
+	// SkipDefaultTransaction 写操作不使用默认事务
+	SkipDefaultTransaction bool `yaml:"skip_default_transaction" json:"skip_default_transaction" toml:"skip_default_transaction" mapstructure:"skip_default_transaction"`
+	// TablePrefix 表名前缀
+	TablePrefix string `yaml:"table_prefix" json:"table_prefix" toml:"table_prefix" mapstructure:"table_prefix"`
+	// SingularTable 使用单数表名
+	SingularTable bool `yaml:"singular_table" json:"singular_table" toml:"singular_table" mapstructure:"singular_table"`
+	// DisableForeignKeyConstraintWhenMigrating 迁移时不创建外键约束
+	DisableForeignKeyConstraintWhenMigrating bool `yaml:"disable_foreign_key_constraint_when_migrating" json:"disable_foreign_key_constraint_when_migrating" toml:"disable_foreign_key_constraint_when_migrating" mapstructure:"disable_foreign_key_constraint_when_migrating"`
+	// GormConfig 自定义 GORM 配置（可选，用于设置 Logger、NowFunc、NamingStrategy 等不可序列化的选项）
+	GormConfig *gorm.Config `yaml:"-" json:"-" toml:"-" mapstructure:"-"`
+
+	// SQL 日志：设置 LogLevel 后使用 NewSlogLogger 输出到 log/slog，参见 BuildLogger
+
+	// LogLevel 日志级别：silent、error、warn、info，为空时不配置日志
+	LogLevel string `yaml:"log_level" json:"log_level" toml:"log_level" mapstructure:"log_level"`
+	// SlowThreshold 慢查询阈值，超过该耗时的查询以 warn 级别记录，为 0 时不记录慢查询
+	SlowThreshold time.Duration `yaml:"slow_threshold" json:"slow_threshold" toml:"slow_threshold" mapstructure:"slow_threshold"`
+	// IgnoreRecordNotFoundError 不把 gorm.ErrRecordNotFound 记录为错误
+	IgnoreRecordNotFoundError bool `yaml:"ignore_record_not_found_error" json:"ignore_record_not_found_error" toml:"ignore_record_not_found_error" mapstructure:"ignore_record_not_found_error"`
+	// ParameterizedQueries 日志中只记录带占位符的 SQL，不记录参数值
+	ParameterizedQueries bool `yaml:"parameterized_queries" json:"parameterized_queries" toml:"parameterized_queries" mapstructure:"parameterized_queries"`
+	// Logger 日志输出目标（可选，为 nil 时使用 slog.Default()）
+	Logger *slog.Logger `yaml:"-" json:"-" toml:"-" mapstructure:"-"`
+
+	// 读写分离：配置从库后 Get 返回的连接写操作使用主库、读操作使用从库，参见 UsePrimary
+
+	// Replicas 从库列表，为空时不启用读写分离
+	Replicas []ReplicaConfig `yaml:"replicas,omitempty" json:"replicas,omitempty" toml:"replicas,omitempty" mapstructure:"replicas"`
+	// ReplicaPolicy 从库选择策略：random（默认）、round_robin、least_conn
+	ReplicaPolicy string `yaml:"replica_policy" json:"replica_policy" toml:"replica_policy" mapstructure:"replica_policy"`
+	// ReplicaProbeInterval 从库健康探测间隔，为 0 时不探测；探测失败的从库会被暂时摘除，读操作改用其他从库或主库
+	ReplicaProbeInterval time.Duration `yaml:"replica_probe_interval" json:"replica_probe_interval" toml:"replica_probe_interval" mapstructure:"replica_probe_interval"`
+	// ReplicaProbeTimeout 单次探测的超时时间，为 0 时使用 ReplicaProbeInterval
+	ReplicaProbeTimeout time.Duration `yaml:"replica_probe_timeout" json:"replica_probe_timeout" toml:"replica_probe_timeout" mapstructure:"replica_probe_timeout"`
+	// ReplicaEjectAfter 连续探测失败多少次后摘除从库，为 0 时使用 DefaultReplicaEjectAfter
+	ReplicaEjectAfter int `yaml:"replica_eject_after" json:"replica_eject_after" toml:"replica_eject_after" mapstructure:"replica_eject_after"`
+	// ReplicaReinstateAfter 被摘除的从库连续探测成功多少次后恢复，为 0 时使用 DefaultReplicaReinstateAfter
+	ReplicaReinstateAfter int `yaml:"replica_reinstate_after" json:"replica_reinstate_after" toml:"replica_reinstate_after" mapstructure:"replica_reinstate_after"`
+	// OnReplicaEvent 从库被摘除或恢复时的回调（可选），在探测协程中调用，不应阻塞
+	OnReplicaEvent func(ReplicaEvent) `yaml:"-" json:"-" toml:"-" mapstructure:"-"`
+
+	// 连接重试：opener 打开连接失败且错误可重试时按指数退避重试，参见 DriverSpec.IsRetryable
+
+	// RetryMaxAttempts 最大尝试次数（包含首次），0 或 1 表示不重试
+	RetryMaxAttempts int `yaml:"retry_max_attempts" json:"retry_max_attempts" toml:"retry_max_attempts" mapstructure:"retry_max_attempts"`
+	// RetryInitialBackoff 首次重试前的等待时间，之后每次翻倍，为 0 时使用 DefaultRetryInitialBackoff
+	RetryInitialBackoff time.Duration `yaml:"retry_initial_backoff" json:"retry_initial_backoff" toml:"retry_initial_backoff" mapstructure:"retry_initial_backoff"`
+	// RetryMaxBackoff 单次等待时间的上限，为 0 时使用 DefaultRetryMaxBackoff
+	RetryMaxBackoff time.Duration `yaml:"retry_max_backoff" json:"retry_max_backoff" toml:"retry_max_backoff" mapstructure:"retry_max_backoff"`
+	// RetryJitter 随机抖动比例（0~1），实际等待时间在 [backoff*(1-jitter), backoff] 之间随机，避免多个实例同时重试
+	RetryJitter float64 `yaml:"retry_jitter" json:"retry_jitter" toml:"retry_jitter" mapstructure:"retry_jitter"`
+
+	// 凭据轮换：Password、DSN 可以是密钥引用，参见 SecretProvider 与 Rotate
+
+	// DynamicCredentials 每个新的物理连接都重新解析密钥引用，短期凭据更新后新连接自动使用新凭据；
+	// 已建立的连接不受影响，应配合小于凭据有效期的 ConnMaxLifetime 使用。显式设置 Dialector 时不生效
+	DynamicCredentials bool `yaml:"dynamic_credentials" json:"dynamic_credentials" toml:"dynamic_credentials" mapstructure:"dynamic_credentials"`
+
+	groupName string // groupName 连接所属的组名，由 Group.Register 设置
+	connName  string // connName 连接在组内的名称，由 Group.Register 设置
+	regID     uint64 // regID 注册序号，由 Group.Register 设置，用于把 Get 在锁外打开的连接交给 opener
+
+	sharedFrom string // sharedFrom 共用其连接池的连接名，由 RegisterToSchema 设置
 }
 
-// AutoDsn 如果 DSN 为空，则根据其他字段自动生成
-func (c *DBConfig) AutoDsn() string {
-	if c.DSN != "" {
-		return c.DSN
+// dbConfigAlias 与 DBConfig 字段相同但不带方法，用于在 JSON 编解码时避免递归调用。
+type dbConfigAlias DBConfig
+
+// dbConfigJSON 是 DBConfig 的 JSON 表示，
+// 其中 time.Duration 字段被替换为 jsonDuration，以支持 "30m" 形式的字符串。
+type dbConfigJSON struct {
+	*dbConfigAlias
+	ConnMaxLifetime jsonDuration `json:"conn_max_lifetime"`
+	ConnMaxIdleTime jsonDuration `json:"conn_max_idle_time"`
+	SlowThreshold   jsonDuration `json:"slow_threshold"`
+
+	RetryInitialBackoff jsonDuration `json:"retry_initial_backoff"`
+	RetryMaxBackoff     jsonDuration `json:"retry_max_backoff"`
+
+	ReplicaProbeInterval jsonDuration `json:"replica_probe_interval"`
+	ReplicaProbeTimeout  jsonDuration `json:"replica_probe_timeout"`
+}
+
+// MarshalJSON 实现 json.Marshaler，time.Duration 字段编码为 "30m0s" 形式的字符串。
+func (c DBConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dbConfigJSON{
+		dbConfigAlias:   (*dbConfigAlias)(&c),
+		ConnMaxLifetime: jsonDuration(c.ConnMaxLifetime),
+		ConnMaxIdleTime: jsonDuration(c.ConnMaxIdleTime),
+		SlowThreshold:   jsonDuration(c.SlowThreshold),
+
+		RetryInitialBackoff: jsonDuration(c.RetryInitialBackoff),
+		RetryMaxBackoff:     jsonDuration(c.RetryMaxBackoff),
+
+		ReplicaProbeInterval: jsonDuration(c.ReplicaProbeInterval),
+		ReplicaProbeTimeout:  jsonDuration(c.ReplicaProbeTimeout),
+	})
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，time.Duration 字段同时支持字符串和纳秒整数。
+func (c *DBConfig) UnmarshalJSON(data []byte) error {
+	return decodeDBConfigJSON(data, c, false)
+}
+
+// decodeDBConfigJSON 将 data 解码到 c，strict 为 true 时遇到未知字段返回错误。
+func decodeDBConfigJSON(data []byte, c *DBConfig, strict bool) error {
+	aux := dbConfigJSON{
+		dbConfigAlias:   (*dbConfigAlias)(c),
+		ConnMaxLifetime: jsonDuration(c.ConnMaxLifetime),
+		ConnMaxIdleTime: jsonDuration(c.ConnMaxIdleTime),
+		SlowThreshold:   jsonDuration(c.SlowThreshold),
+
+		RetryInitialBackoff: jsonDuration(c.RetryInitialBackoff),
+		RetryMaxBackoff:     jsonDuration(c.RetryMaxBackoff),
+
+		ReplicaProbeInterval: jsonDuration(c.ReplicaProbeInterval),
+		ReplicaProbeTimeout:  jsonDuration(c.ReplicaProbeTimeout),
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&aux); err != nil {
+		return err
+	}
+
+	c.ConnMaxLifetime = time.Duration(aux.ConnMaxLifetime)
+	c.ConnMaxIdleTime = time.Duration(aux.ConnMaxIdleTime)
+	c.SlowThreshold = time.Duration(aux.SlowThreshold)
+	c.RetryInitialBackoff = time.Duration(aux.RetryInitialBackoff)
+	c.RetryMaxBackoff = time.Duration(aux.RetryMaxBackoff)
+	c.ReplicaProbeInterval = time.Duration(aux.ReplicaProbeInterval)
+	c.ReplicaProbeTimeout = time.Duration(aux.ReplicaProbeTimeout)
+	return nil
+}
+
+// jsonDuration 是 JSON 中的 time.Duration，编码为字符串，解码时同时支持字符串和纳秒整数。
+type jsonDuration time.Duration
+
+// MarshalJSON 实现 json.Marshaler。
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler。
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
 	}
 
-	dsn := ""
-	switch c.DriverType {
-	case "mysql":
-		if c.Charset == "" {
-			c.Charset = "utf8mb4"
+	switch value := v.(type) {
+	case float64:
+		*d = jsonDuration(time.Duration(value))
+	case string:
+		dur, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("mgorm: invalid duration %q: %w", value, err)
 		}
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
-			c.User, c.Password, c.Host, c.Port, c.DBName, c.Charset)
-	case "postgres":
-		dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-			c.Host, c.Port, c.User, c.Password, c.DBName)
-	case "sqlite":
-		dsn = c.DBName // SQLite 直接用文件路径或 ":memory:"
-	case "sqlserver":
-		dsn = fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s",
-			c.User, c.Password, c.Host, c.Port, c.DBName)
+		*d = jsonDuration(dur)
+	case nil:
+		*d = 0
 	default:
-		dsn = ""
+		return fmt.Errorf("mgorm: invalid duration %s", data)
+	}
+	return nil
+}
+
+// AutoDsn 如果 DSN 为空，则使用已注册驱动的 DSN 生成函数根据其他字段自动生成。
+// 驱动未注册或不支持生成 DSN 时返回空字符串。
+func (c *DBConfig) AutoDsn() string {
+	if c.DSN != "" {
+		return c.DSN
 	}
 
-	return dsn
+	spec, ok := lookupDriver(c.DriverType)
+	if !ok || spec.DSN == nil {
+		return ""
+	}
+	return spec.DSN(c)
 }
 
 // Validate 验证数据库配置是否有效
+//
+// 日志级别无效、MaxIdleConns 大于 MaxOpenConns、重试参数无效或从库配置无效时返回错误。
+// 然后按以下优先级检查能否得到 Dialector，并准确报告缺少的输入：
+//  1. 设置了 Dialector：有效
+//  2. 设置了 DSN：必须提供受支持的 DriverType
+//  3. 未设置 DSN：必须提供 DriverType 以及该驱动 DriverSpec.RequiredFields 中的字段（如 host、db_name）
 func (c *DBConfig) Validate() error {
-	// 如果没有提供 Dialector，则必须提供 DSN
-	if c.Dialector == nil {
-		if c.DSN == "" {
-			return errNoDSN
-		}
-		return errNoDialector
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		return err
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		return fmt.Errorf("%w: max_idle_conns (%d) must not exceed max_open_conns (%d)",
+			errInvalidPool, c.MaxIdleConns, c.MaxOpenConns)
+	}
+	if err := c.validateRetry(); err != nil {
+		return err
+	}
+	if err := c.validateReplicas(); err != nil {
+		return err
+	}
+
+	if c.Dialector != nil {
+		return nil
+	}
+
+	// 既没有 DSN 也没有可用于生成 DSN 的驱动类型
+	if c.DSN == "" && c.DriverType == "" {
+		return errNoDSN
+	}
+	if c.DriverType == "" {
+		return fmt.Errorf("%w: %w", errNoDialector, errNoDriverType)
+	}
+
+	spec, ok := lookupDriver(c.DriverType)
+	if !ok {
+		return fmt.Errorf("%w: %w", errNoDialector, newErrUnknownDriverType(c.DriverType))
+	}
+	if c.DSN != "" {
+		return nil
+	}
+
+	if spec.DSN == nil {
+		return fmt.Errorf("%w: driver %q cannot build DSN from fields", errNoDSN, c.DriverType)
+	}
+	if missing := c.missingFields(spec.RequiredFields); len(missing) > 0 {
+		return fmt.Errorf("%w: driver %q requires %s", errNoDSN, c.DriverType, strings.Join(missing, ", "))
 	}
 	return nil
 }
 
+// missingFields 返回 keys 中值为空的字段
+func (c *DBConfig) missingFields(keys []string) []string {
+	var missing []string
+	for _, key := range keys {
+		empty := false
+		switch key {
+		case "host":
+			empty = c.Host == ""
+		case "port":
+			empty = c.Port == 0
+		case "user":
+			empty = c.User == ""
+		case "password":
+			empty = c.Password == ""
+		case "db_name":
+			empty = c.DBName == ""
+		}
+		if empty {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// ResolveDialector 返回用于打开连接的 Dialector，优先级如下：
+//  1. 显式设置的 Dialector
+//  2. DSN + DriverType，通过 CreateDialector 创建
+//  3. 根据 Host、Port 等字段通过 AutoDsn 生成 DSN，再通过 CreateDialector 创建
+//
+// 配置无效时返回 Validate 的错误。
+func (c *DBConfig) ResolveDialector() (gorm.Dialector, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.Dialector != nil {
+		return c.Dialector, nil
+	}
+
+	cfg := *c
+	return CreateDialector(cfg.DriverType, cfg.AutoDsn())
+}
+
+// BuildGormConfig 返回打开连接时使用的 gorm.Config。
+//
+// 以 GormConfig 的副本为基础（为 nil 时使用零值），再应用 PrepareStmt、SkipDefaultTransaction、
+// DisableForeignKeyConstraintWhenMigrating、TablePrefix、SingularTable 等可序列化字段，
+// 设置了 LogLevel 时 Logger 替换为 BuildLogger 的结果。
+// TablePrefix 与 SingularTable 仅在 NamingStrategy 为空或为 schema.NamingStrategy 时生效。
+// GormConfig 本身不会被修改。
+func (c *DBConfig) BuildGormConfig() *gorm.Config {
+	gormConfig := &gorm.Config{}
+	if c.GormConfig != nil {
+		*gormConfig = *c.GormConfig
+	}
+
+	if c.PrepareStmt {
+		gormConfig.PrepareStmt = true
+	}
+	if c.SkipDefaultTransaction {
+		gormConfig.SkipDefaultTransaction = true
+	}
+	if c.DisableForeignKeyConstraintWhenMigrating {
+		gormConfig.DisableForeignKeyConstraintWhenMigrating = true
+	}
+
+	if gormLogger := c.BuildLogger(); gormLogger != nil {
+		gormConfig.Logger = gormLogger
+	}
+
+	if c.TablePrefix != "" || c.SingularTable {
+		var naming schema.NamingStrategy
+		switch ns := gormConfig.NamingStrategy.(type) {
+		case nil:
+		case schema.NamingStrategy:
+			naming = ns
+		case *schema.NamingStrategy:
+			naming = *ns
+		default:
+			// 自定义 NamingStrategy 优先
+			return gormConfig
+		}
+		if c.TablePrefix != "" {
+			naming.TablePrefix = c.TablePrefix
+		}
+		if c.SingularTable {
+			naming.SingularTable = true
+		}
+		gormConfig.NamingStrategy = naming
+	}
+
+	return gormConfig
+}
+
+// applyPool 将连接池参数应用到 sqlDB。
+//
+// 为 0 的参数视为未设置，保留 database/sql 的默认值；
+// MaxOpenConns 为负数表示不限制，MaxIdleConns 为负数表示不保留空闲连接，
+// ConnMaxLifetime、ConnMaxIdleTime 为负数表示连接不因存活或空闲时间过长而关闭。
+func (c *DBConfig) applyPool(sqlDB *sql.DB) {
+	if c.MaxOpenConns != 0 {
+		sqlDB.SetMaxOpenConns(max(c.MaxOpenConns, 0))
+	}
+	if c.MaxIdleConns != 0 {
+		sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime != 0 {
+		sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime != 0 {
+		sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+}
+
 // dbConnection 数据库连接信息
 type dbConnection struct {
 	db     *gorm.DB // 数据库连接实例
@@ -73,18 +394,14 @@ type dbConnection struct {
 
 // openDB 根据配置创建数据库连接
 func openDB(cfg DBConfig) (*gorm.DB, error) {
-	var dialector gorm.Dialector
-
-	// 验证配置
-	if err := cfg.Validate(); err != nil {
+	// 验证配置并解析 Dialector
+	dialector, err := cfg.ResolveDialector()
+	if err != nil {
 		return nil, err
 	}
 
-	// 使用自定义 Dialector
-	dialector = cfg.Dialector
-
 	// 打开数据库连接
-	db, err := gorm.Open(dialector, &gorm.Config{})
+	db, err := gorm.Open(dialector, cfg.BuildGormConfig())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -96,15 +413,7 @@ func openDB(cfg DBConfig) (*gorm.DB, error) {
 	}
 
 	// 配置连接池
-	if cfg.MaxIdleConns > 0 {
-		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	}
-	if cfg.MaxOpenConns > 0 {
-		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	}
-	if cfg.ConnMaxLifetime > 0 {
-		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
-	}
+	cfg.applyPool(sqlDB)
 
 	// 测试连接
 	if err := sqlDB.Ping(); err != nil {
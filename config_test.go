@@ -1,6 +1,11 @@
 package mgorm
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,11 +16,12 @@ import (
 // TestDBConfig_Validate_TableDriven 使用表驱动测试验证数据库配置校验
 func TestDBConfig_Validate_TableDriven(t *testing.T) {
 	tests := []struct {
-		name        string
-		config      DBConfig
-		expectError bool
-		checkNoDSN  bool // 是否检查错误类型为 NoDSN
-		checkNoDial bool // 是否检查错误类型为 NoDialector
+		name          string
+		config        DBConfig
+		expectError   bool
+		checkNoDSN    bool // 是否检查错误类型为 NoDSN
+		checkNoDial   bool // 是否检查错误类型为 NoDialector
+		checkNoDriver bool // 是否检查错误类型为 NoDriverType
 	}{
 		{
 			name: "有效配置：提供 Dialector",
@@ -47,14 +53,49 @@ func TestDBConfig_Validate_TableDriven(t *testing.T) {
 			checkNoDSN:  true,
 		},
 		{
-			name: "无效配置：只有 DSN 没有 Dialector",
+			name: "有效配置：DSN + DriverType",
 			config: DBConfig{
 				DSN:        "user:pass@tcp(localhost:3306)/dbname",
 				DriverType: "mysql",
 			},
+			expectError: false,
+		},
+		{
+			name: "有效配置：根据字段自动生成 DSN",
+			config: DBConfig{
+				DriverType: "mysql",
+				Host:       "localhost",
+				Port:       3306,
+				DBName:     "dbname",
+			},
+			expectError: false,
+		},
+		{
+			name: "无效配置：只有 DSN 没有 DriverType",
+			config: DBConfig{
+				DSN: "user:pass@tcp(localhost:3306)/dbname",
+			},
+			expectError:   true,
+			checkNoDial:   true,
+			checkNoDriver: true,
+		},
+		{
+			name: "无效配置：未知的 DriverType",
+			config: DBConfig{
+				DSN:        "whatever",
+				DriverType: "oracle",
+			},
 			expectError: true,
 			checkNoDial: true,
 		},
+		{
+			name: "无效配置：自动生成 DSN 缺少 db_name",
+			config: DBConfig{
+				DriverType: "sqlite",
+			},
+			expectError: true,
+			checkNoDSN:  true,
+		},
 		{
 			name: "无效配置：空 DSN 字符串",
 			config: DBConfig{
@@ -80,6 +121,9 @@ func TestDBConfig_Validate_TableDriven(t *testing.T) {
 				if tt.checkNoDial && !IsErrNoDialector(err) {
 					t.Errorf("错误应为 NoDialector 类型，实际为: %v", err)
 				}
+				if tt.checkNoDriver && !IsErrNoDriverType(err) {
+					t.Errorf("错误应为 NoDriverType 类型，实际为: %v", err)
+				}
 			} else {
 				if err != nil {
 					t.Errorf("Validate() 不应返回错误，实际为: %v", err)
@@ -469,6 +513,19 @@ func TestDBConfig_AutoDsn(t *testing.T) {
 			},
 			expected: "host=localhost port=5432 user=postgres password=pgpass dbname=postgresdb sslmode=disable",
 		},
+		{
+			name: "PostgreSQL 驱动，设置 search_path",
+			config: DBConfig{
+				DriverType: "postgres",
+				Host:       "localhost",
+				Port:       5432,
+				User:       "postgres",
+				Password:   "pgpass",
+				DBName:     "postgresdb",
+				SearchPath: "tenant_a",
+			},
+			expected: "host=localhost port=5432 user=postgres password=pgpass dbname=postgresdb sslmode=disable search_path=tenant_a",
+		},
 		{
 			name: "SQLite 驱动，文件数据库",
 			config: DBConfig{
@@ -720,3 +777,252 @@ func BenchmarkDBConfig_AutoDsn_MySQL(b *testing.B) {
 		_ = config.AutoDsn()
 	}
 }
+
+// TestDBConfig_JSON 测试 DBConfig 的 JSON 编解码
+func TestDBConfig_JSON(t *testing.T) {
+	config := DBConfig{
+		Name:            "测试",
+		DriverType:      "sqlite",
+		DBName:          ":memory:",
+		ConnMaxLifetime: time.Hour,
+		Dialector:       sqlite.Open(":memory:"),
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("json.Marshal() 失败: %v", err)
+	}
+	if !strings.Contains(string(data), `"conn_max_lifetime":"1h0m0s"`) {
+		t.Errorf("conn_max_lifetime 应编码为字符串，实际为: %s", data)
+	}
+	if strings.Contains(string(data), "Dialector") {
+		t.Errorf("Dialector 不应被编码，实际为: %s", data)
+	}
+
+	var decoded DBConfig
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() 失败: %v", err)
+	}
+	config.Dialector = nil
+	if !reflect.DeepEqual(decoded, config) {
+		t.Errorf("往返结果 = %+v, 期望 %+v", decoded, config)
+	}
+
+	// 兼容纳秒整数写法
+	if err := json.Unmarshal([]byte(`{"conn_max_lifetime": 1000000000}`), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() 失败: %v", err)
+	}
+	if decoded.ConnMaxLifetime != time.Second {
+		t.Errorf("ConnMaxLifetime = %v, 期望 1s", decoded.ConnMaxLifetime)
+	}
+
+	if err := json.Unmarshal([]byte(`{"conn_max_lifetime": "abc"}`), &decoded); err == nil {
+		t.Error("无效的 duration 应返回错误")
+	}
+}
+
+// TestDBConfig_Validate_MissingFields 测试自动生成 DSN 时报告缺少的字段
+func TestDBConfig_Validate_MissingFields(t *testing.T) {
+	config := DBConfig{
+		DriverType: "postgres",
+		DBName:     "app",
+	}
+	err := config.Validate()
+	if !IsErrNoDSN(err) {
+		t.Fatalf("错误应为 NoDSN 类型，实际为: %v", err)
+	}
+	if !strings.Contains(err.Error(), "host") {
+		t.Errorf("错误信息应包含缺少的字段 host，实际为: %v", err)
+	}
+}
+
+// TestDBConfig_ResolveDialector 测试 Dialector 的解析优先级
+func TestDBConfig_ResolveDialector(t *testing.T) {
+	explicit := sqlite.Open(":memory:")
+	tests := []struct {
+		name   string
+		config DBConfig
+		want   string
+	}{
+		{
+			name:   "优先使用显式 Dialector",
+			config: DBConfig{Dialector: explicit, DriverType: "mysql", DSN: "ignored"},
+			want:   "sqlite",
+		},
+		{
+			name:   "DSN + DriverType",
+			config: DBConfig{DriverType: "sqlite", DSN: ":memory:"},
+			want:   "sqlite",
+		},
+		{
+			name:   "根据字段自动生成 DSN",
+			config: DBConfig{DriverType: "postgres", Host: "localhost", Port: 5432},
+			want:   "postgres",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialector, err := tt.config.ResolveDialector()
+			if err != nil {
+				t.Fatalf("ResolveDialector() 失败: %v", err)
+			}
+			if dialector.Name() != tt.want {
+				t.Errorf("Dialector.Name() = %q, 期望 %q", dialector.Name(), tt.want)
+			}
+		})
+	}
+
+	if _, err := (&DBConfig{}).ResolveDialector(); !IsErrNoDSN(err) {
+		t.Errorf("空配置应返回 NoDSN 错误，实际为: %v", err)
+	}
+}
+
+// TestDBConfig_BuildGormConfig 测试可序列化字段与 GormConfig 的合并
+func TestDBConfig_BuildGormConfig(t *testing.T) {
+	now := func() time.Time { return time.Unix(0, 0) }
+	base := &gorm.Config{NowFunc: now}
+	config := DBConfig{
+		PrepareStmt:                              true,
+		SkipDefaultTransaction:                   true,
+		TablePrefix:                              "t_",
+		SingularTable:                            true,
+		DisableForeignKeyConstraintWhenMigrating: true,
+		GormConfig:                               base,
+	}
+
+	gormConfig := config.BuildGormConfig()
+	if !gormConfig.PrepareStmt || !gormConfig.SkipDefaultTransaction || !gormConfig.DisableForeignKeyConstraintWhenMigrating {
+		t.Errorf("布尔选项未生效: %+v", gormConfig)
+	}
+	if gormConfig.NowFunc == nil || !gormConfig.NowFunc().Equal(now()) {
+		t.Error("应保留 GormConfig 中的 NowFunc")
+	}
+	if name := gormConfig.NamingStrategy.TableName("UserInfo"); name != "t_user_info" {
+		t.Errorf("TableName() = %q, 期望 t_user_info", name)
+	}
+
+	// GormConfig 本身不应被修改
+	if base.PrepareStmt || base.NamingStrategy != nil {
+		t.Error("BuildGormConfig 不应修改 GormConfig")
+	}
+
+	// 未设置任何选项时返回零值配置
+	if empty := (&DBConfig{}).BuildGormConfig(); empty.PrepareStmt || empty.NamingStrategy != nil {
+		t.Errorf("空配置应返回零值 gorm.Config: %+v", empty)
+	}
+}
+
+// TestOpenDB_GormConfig 测试打开连接时应用 GORM 配置
+func TestOpenDB_GormConfig(t *testing.T) {
+	config := DBConfig{
+		Dialector:     sqlite.Open(":memory:"),
+		PrepareStmt:   true,
+		TablePrefix:   "app_",
+		SingularTable: true,
+	}
+
+	db, err := openDB(config)
+	if err != nil {
+		t.Fatalf("openDB() 失败: %v", err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	if !db.PrepareStmt {
+		t.Error("PrepareStmt 未生效")
+	}
+	if err := db.AutoMigrate(&TestModel{}); err != nil {
+		t.Fatalf("AutoMigrate() 失败: %v", err)
+	}
+	if !db.Migrator().HasTable("app_test_model") {
+		t.Error("应创建带前缀的单数表名 app_test_model")
+	}
+}
+
+// TestDBConfig_Validate_Pool 测试 MaxIdleConns 不能大于 MaxOpenConns
+func TestDBConfig_Validate_Pool(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxIdleConns int
+		maxOpenConns int
+		expectError  bool
+	}{
+		{name: "空闲连接数小于最大连接数", maxIdleConns: 5, maxOpenConns: 10},
+		{name: "空闲连接数等于最大连接数", maxIdleConns: 10, maxOpenConns: 10},
+		{name: "最大连接数未设置", maxIdleConns: 10, maxOpenConns: 0},
+		{name: "最大连接数不限制", maxIdleConns: 10, maxOpenConns: -1},
+		{name: "空闲连接数大于最大连接数", maxIdleConns: 20, maxOpenConns: 10, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DBConfig{
+				Dialector:    sqlite.Open(":memory:"),
+				MaxIdleConns: tt.maxIdleConns,
+				MaxOpenConns: tt.maxOpenConns,
+			}
+			err := config.Validate()
+			if tt.expectError != (err != nil) {
+				t.Fatalf("Validate() 错误 = %v, 期望出错 %v", err, tt.expectError)
+			}
+			if tt.expectError && !IsErrInvalidPool(err) {
+				t.Errorf("错误应为 InvalidPool 类型，实际为: %v", err)
+			}
+		})
+	}
+}
+
+// TestOpenDB_PoolUnsetAndUnlimited 测试连接池参数“未设置”与“不限制”的区别
+func TestOpenDB_PoolUnsetAndUnlimited(t *testing.T) {
+	ctx := context.Background()
+
+	open := func(cfg DBConfig) *sql.DB {
+		cfg.Dialector = sqlite.Open(":memory:")
+		db, err := opener(ctx, cfg)
+		if err != nil {
+			t.Fatalf("opener() 失败: %v", err)
+		}
+		sqlDB, _ := db.DB()
+		t.Cleanup(func() { sqlDB.Close() })
+		return sqlDB
+	}
+
+	// 先限制为 5，再通过负数显式取消限制
+	limited := open(DBConfig{MaxOpenConns: 5})
+	if got := limited.Stats().MaxOpenConnections; got != 5 {
+		t.Errorf("MaxOpenConnections = %d, 期望 5", got)
+	}
+	unlimited := open(DBConfig{MaxOpenConns: -1, ConnMaxIdleTime: time.Minute})
+	if got := unlimited.Stats().MaxOpenConnections; got != 0 {
+		t.Errorf("MaxOpenConns = -1 时 MaxOpenConnections = %d, 期望 0（不限制）", got)
+	}
+
+	// openDB 与 opener 使用相同的连接池设置
+	db, err := openDB(DBConfig{Dialector: sqlite.Open(":memory:"), MaxOpenConns: 3, ConnMaxIdleTime: time.Minute})
+	if err != nil {
+		t.Fatalf("openDB() 失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	if got := sqlDB.Stats().MaxOpenConnections; got != 3 {
+		t.Errorf("MaxOpenConnections = %d, 期望 3", got)
+	}
+
+	if _, err := openDB(DBConfig{Dialector: sqlite.Open(":memory:"), MaxIdleConns: 5, MaxOpenConns: 1}); !IsErrInvalidPool(err) {
+		t.Errorf("openDB() 应返回 InvalidPool 错误，实际为: %v", err)
+	}
+}
+
+// TestDBConfig_ConnMaxIdleTimeDecode 测试 conn_max_idle_time 的解码
+func TestDBConfig_ConnMaxIdleTimeDecode(t *testing.T) {
+	var config DBConfig
+	if err := json.Unmarshal([]byte(`{"conn_max_idle_time": "5m"}`), &config); err != nil {
+		t.Fatalf("json.Unmarshal() 失败: %v", err)
+	}
+	if config.ConnMaxIdleTime != 5*time.Minute {
+		t.Errorf("ConnMaxIdleTime = %v, 期望 5m", config.ConnMaxIdleTime)
+	}
+}
@@ -0,0 +1,78 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// openDialector 解析密钥引用后返回用于打开连接的 Dialector。
+// 设置了 DynamicCredentials 时返回的 Dialector 打开的连接池在每个新的物理连接上重新解析密钥引用；
+// 显式设置了 Dialector 时 DynamicCredentials 不生效。
+func (c *DBConfig) openDialector(ctx context.Context) (gorm.Dialector, error) {
+	resolved, err := c.resolveSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dialector, err := resolved.ResolveDialector()
+	if err != nil || !c.DynamicCredentials || c.Dialector != nil {
+		return dialector, err
+	}
+	return credentialDialector{Dialector: dialector, cfg: *c}, nil
+}
+
+// credentialDialector 由原始方言完成初始化（注册回调、查询版本等），
+// 再改用通过 credentialConnector 创建连接的连接池
+type credentialDialector struct {
+	gorm.Dialector
+	cfg DBConfig // cfg 未解析密钥引用的配置
+}
+
+// Initialize 实现 gorm.Dialector
+func (d credentialDialector) Initialize(db *gorm.DB) error {
+	if err := d.Dialector.Initialize(db); err != nil {
+		return err
+	}
+	sqlDB, ok := db.ConnPool.(*sql.DB)
+	if !ok {
+		return fmt.Errorf("mgorm: dynamic_credentials requires a *sql.DB connection pool, got %T", db.ConnPool)
+	}
+
+	db.ConnPool = sql.OpenDB(&credentialConnector{cfg: d.cfg, driver: sqlDB.Driver()})
+	// 原始方言初始化时打开的连接池不再使用
+	_ = sqlDB.Close()
+	return nil
+}
+
+// credentialConnector 每次创建物理连接时重新解析密钥引用并生成 DSN，
+// 使连接池中的新连接总是使用最新的凭据
+type credentialConnector struct {
+	cfg    DBConfig      // cfg 未解析密钥引用的配置
+	driver driver.Driver // driver 原始方言使用的驱动
+}
+
+// Connect 实现 driver.Connector
+func (c *credentialConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	resolved, err := c.cfg.resolveSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dsn := resolved.AutoDsn()
+
+	if driverCtx, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := driverCtx.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+	return c.driver.Open(dsn)
+}
+
+// Driver 实现 driver.Connector
+func (c *credentialConnector) Driver() driver.Driver {
+	return c.driver
+}
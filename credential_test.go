@@ -0,0 +1,102 @@
+package mgorm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// TestDynamicCredentials 测试设置 DynamicCredentials 后每个新的物理连接都使用最新的凭据
+func TestDynamicCredentials(t *testing.T) {
+	ctx := context.Background()
+	registerSecretSQLiteDriver(t)
+	dir := t.TempDir()
+
+	var resolved atomic.Int32
+	password := atomic.Value{}
+	password.Store("v1")
+	RegisterSecretProvider("rotating", SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		resolved.Add(1)
+		return password.Load().(string), nil
+	}))
+	t.Cleanup(func() { unregisterSecretProvider("rotating") })
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "app", DBConfig{
+		DriverType:         "secret_sqlite",
+		Host:               dir,
+		DBName:             "app",
+		Password:           "rotating:app",
+		DynamicCredentials: true,
+	})
+	db := group.MustGet(ctx, "app")
+	if db.Dialector.Name() != "sqlite" {
+		t.Errorf("应恢复原始方言，实际为 %T", db.Dialector)
+	}
+
+	password.Store("v2")
+	before := resolved.Load()
+	sqlDB, _ := db.DB()
+	// 关闭空闲连接，下一次查询创建新的物理连接
+	sqlDB.SetMaxIdleConns(-1)
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatalf("Exec() 失败: %v", err)
+	}
+	if resolved.Load() == before {
+		t.Error("新的物理连接应重新解析密钥引用")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app_v2.db")); err != nil {
+		t.Errorf("新的物理连接应使用新凭据: %v", err)
+	}
+}
+
+// TestDynamicCredentials_Disabled 测试未设置 DynamicCredentials 时只在打开时解析一次
+func TestDynamicCredentials_Disabled(t *testing.T) {
+	ctx := context.Background()
+	registerSecretSQLiteDriver(t)
+	dir := t.TempDir()
+	t.Setenv("MGORM_TEST_SECRET", "v1")
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "app", DBConfig{DriverType: "secret_sqlite", Host: dir, DBName: "app", Password: "env:MGORM_TEST_SECRET"})
+	db := group.MustGet(ctx, "app")
+
+	t.Setenv("MGORM_TEST_SECRET", "v2")
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxIdleConns(-1)
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatalf("Exec() 失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app_v2.db")); !os.IsNotExist(err) {
+		t.Errorf("未设置 DynamicCredentials 时应继续使用打开时的凭据: %v", err)
+	}
+}
+
+// TestLoadManager_DynamicCredentials 测试从配置文件加载的连接设置 DynamicCredentials 时不在注册时生成 Dialector
+func TestLoadManager_DynamicCredentials(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	manager, err := LoadManager(ctx, ManagerConfig{
+		"business": {
+			"app": {DriverType: "sqlite", DBName: filepath.Join(dir, "app.db"), DynamicCredentials: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("LoadManager() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	group := manager.MustGroup("business")
+	if cfg := group.MustConfig(ctx, "app"); cfg.Dialector != nil {
+		t.Error("DynamicCredentials 不应在注册时生成 Dialector")
+	}
+	db := group.MustGet(ctx, "app")
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatalf("Exec() 失败: %v", err)
+	}
+}
@@ -0,0 +1,477 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrDatabaseUnsupported 当驱动不支持创建或删除数据库时返回此错误，参见 DriverSpec.CreateDatabase
+	ErrDatabaseUnsupported = errors.New("mgorm: driver does not support creating or dropping databases")
+	// ErrDropDBNotConfirmed 当调用 DropDB 时未确认删除返回此错误
+	ErrDropDBNotConfirmed = errors.New("mgorm: DropDB requires confirm to be true")
+)
+
+// sqlWordPattern 合法的字符集与排序规则名
+var sqlWordPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// DatabaseOptions 创建数据库的选项，不适用于当前驱动的选项会被忽略
+type DatabaseOptions struct {
+	// Charset MySQL 字符集，为空时使用来源配置的 Charset，仍为空时使用 utf8mb4
+	Charset string
+	// Collation MySQL 排序规则（可选）
+	Collation string
+	// Owner PostgreSQL 数据库所有者（可选）
+	Owner string
+	// Template PostgreSQL 模板数据库（可选）
+	Template string
+}
+
+// CreateDB 使用当前 Group 中已有名称 fromName 的连接，创建名为 dbName 的数据库，已存在时不做任何处理。
+// 驱动未提供 DriverSpec.CreateDatabase 时返回 ErrDatabaseUnsupported。
+func CreateDB(ctx context.Context, group Group, fromName, dbName string, opts DatabaseOptions) error {
+	if dbName == "" {
+		return errors.New("mgorm: CreateDB database name is empty")
+	}
+	cfg, err := group.Config(ctx, fromName)
+	if err != nil {
+		return err
+	}
+	spec, err := cfg.databaseSpec()
+	if err != nil {
+		return err
+	}
+	if spec.CreateDatabase == nil {
+		return fmt.Errorf("%w: create database with %s", ErrDatabaseUnsupported, cfg.DriverType)
+	}
+	if opts.Charset == "" {
+		opts.Charset = cfg.Charset
+	}
+
+	sqlDB, err := adminSQLDB(ctx, group, fromName)
+	if err != nil {
+		return err
+	}
+	if err := spec.CreateDatabase(ctx, sqlDB, dbName, opts); err != nil {
+		return fmt.Errorf("mgorm: create database %q: %w", dbName, err)
+	}
+	return nil
+}
+
+// RegisterToNewDB 与 RegisterToDB 相同，但会先通过 CreateDB 创建 toDBName（已存在时不做处理）。
+// 返回值 isNew 表示 toName 是否为新注册。
+func RegisterToNewDB(ctx context.Context, group Group, fromName, toName, toDBName string, opts DatabaseOptions) (isNew bool, err error) {
+	if err := CreateDB(ctx, group, fromName, toDBName, opts); err != nil {
+		return false, err
+	}
+	return RegisterToDB(ctx, group, fromName, toName, toDBName)
+}
+
+// DropDB 使用当前 Group 中已有名称 fromName 的连接，删除名为 dbName 的数据库，不存在时不做任何处理。
+//
+// 删除不可恢复，confirm 必须为 true，否则返回 ErrDropDBNotConfirmed。
+// 为避免删除正在使用的数据库，dbName 仍被组内任一连接（包括 fromName）使用时返回错误，应先 Unregister；
+// 组由 NewManager 创建时同时检查 Manager 中的其他组。连接使用的数据库通过 DriverSpec.DatabaseName 从 DSN 中解析，
+// 因此显式设置了 DSN 的连接同样会被检查。
+// 驱动未提供 DriverSpec.DropDatabase 时返回 ErrDatabaseUnsupported。
+func DropDB(ctx context.Context, group Group, fromName, dbName string, confirm bool) error {
+	if !confirm {
+		return ErrDropDBNotConfirmed
+	}
+	if dbName == "" {
+		return errors.New("mgorm: DropDB database name is empty")
+	}
+
+	cfg, err := group.Config(ctx, fromName)
+	if err != nil {
+		return err
+	}
+	spec, err := cfg.databaseSpec()
+	if err != nil {
+		return err
+	}
+	if spec.DropDatabase == nil {
+		return fmt.Errorf("%w: drop database with %s", ErrDatabaseUnsupported, cfg.DriverType)
+	}
+	target := cfg
+	target.DSN = ""
+	target.DBName = dbName
+	if err := checkDatabaseUnused(ctx, group, target.databaseName()); err != nil {
+		return fmt.Errorf("mgorm: drop database %q: %w", dbName, err)
+	}
+
+	sqlDB, err := adminSQLDB(ctx, group, fromName)
+	if err != nil {
+		return err
+	}
+	if err := spec.DropDatabase(ctx, sqlDB, dbName); err != nil {
+		return fmt.Errorf("mgorm: drop database %q: %w", dbName, err)
+	}
+	return nil
+}
+
+// checkDatabaseUnused 检查 group 以及同一 Manager 中其他组的连接是否使用名为 database 的数据库
+func checkDatabaseUnused(ctx context.Context, group Group, database string) error {
+	groups := map[string]Group{"": group}
+	if g, ok := mgormGroup(group); ok && g.manager != nil {
+		groups = make(map[string]Group)
+		for _, groupName := range g.manager.ListGroupNames() {
+			if mg, err := g.manager.Group(groupName); err == nil {
+				groups[groupName] = mg
+			}
+		}
+	}
+
+	for _, groupName := range sortedKeys(groups) {
+		g := groups[groupName]
+		for _, name := range g.List() {
+			c, err := g.Config(ctx, name)
+			if err != nil || c.databaseName() != database {
+				continue
+			}
+			if groupName != "" {
+				name = groupName + "/" + name
+			}
+			return fmt.Errorf("still used by %q, unregister it first", name)
+		}
+	}
+	return nil
+}
+
+// databaseName 返回连接使用的数据库名：驱动提供了 DriverSpec.DatabaseName 时从 DSN 中解析，否则使用 DBName
+func (c DBConfig) databaseName() string {
+	spec, err := c.databaseSpec()
+	if err != nil || spec.DatabaseName == nil || (!c.literalDSN() && IsSecretRef(c.DSN)) {
+		return c.DBName
+	}
+	if name := spec.DatabaseName(c.AutoDsn()); name != "" {
+		return name
+	}
+	return c.DBName
+}
+
+// databaseSpec 依次使用 DriverType、Dialector.Name() 查找驱动
+func (c *DBConfig) databaseSpec() (DriverSpec, error) {
+	names := []string{c.DriverType}
+	if c.Dialector != nil {
+		names = append(names, c.Dialector.Name())
+	}
+	for _, name := range names {
+		if spec, ok := lookupDriver(name); ok {
+			return spec, nil
+		}
+	}
+	return DriverSpec{}, newErrUnknownDriverType(c.DriverType)
+}
+
+// adminSQLDB 返回 name 对应连接的主库连接池，用于执行创建、删除数据库的语句
+func adminSQLDB(ctx context.Context, group Group, name string) (*sql.DB, error) {
+	db, err := group.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return db.DB()
+}
+
+// MySQLCreateDatabase 通过 CREATE DATABASE IF NOT EXISTS 创建 MySQL 数据库，使用 opts 中的字符集与排序规则。
+// 也可用于 TiDB 等兼容 MySQL 协议的驱动。
+func MySQLCreateDatabase(ctx context.Context, db *sql.DB, name string, opts DatabaseOptions) error {
+	stmt, err := mysqlCreateDatabaseSQL(name, opts)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, stmt)
+	return err
+}
+
+// MySQLDropDatabase 通过 DROP DATABASE IF EXISTS 删除 MySQL 数据库。
+func MySQLDropDatabase(ctx context.Context, db *sql.DB, name string) error {
+	_, err := db.ExecContext(ctx, "DROP DATABASE IF EXISTS "+quoteIdent(name, '`'))
+	return err
+}
+
+// mysqlCreateDatabaseSQL 生成 MySQL 的建库语句
+func mysqlCreateDatabaseSQL(name string, opts DatabaseOptions) (string, error) {
+	charset := opts.Charset
+	if charset == "" {
+		charset = "utf8mb4"
+	}
+	if !sqlWordPattern.MatchString(charset) {
+		return "", fmt.Errorf("invalid charset %q", charset)
+	}
+
+	stmt := "CREATE DATABASE IF NOT EXISTS " + quoteIdent(name, '`') + " CHARACTER SET " + charset
+	if opts.Collation != "" {
+		if !sqlWordPattern.MatchString(opts.Collation) {
+			return "", fmt.Errorf("invalid collation %q", opts.Collation)
+		}
+		stmt += " COLLATE " + opts.Collation
+	}
+	return stmt, nil
+}
+
+// MySQLDatabaseName 从 MySQL DSN（[user[:password]@][net[(addr)]]/dbname[?params]）中解析数据库名。
+func MySQLDatabaseName(dsn string) string {
+	i := strings.LastIndexByte(dsn, '/')
+	if i < 0 {
+		return ""
+	}
+	name, _, _ := strings.Cut(dsn[i+1:], "?")
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return name
+}
+
+// MySQLSetDatabase 把 MySQL DSN 中的数据库替换为 name，保留其他部分。
+func MySQLSetDatabase(dsn, name string) (string, error) {
+	i := strings.LastIndexByte(dsn, '/')
+	if i < 0 {
+		return "", errors.New("invalid MySQL DSN: missing /dbname")
+	}
+	out := dsn[:i+1] + url.PathEscape(name)
+	if _, params, ok := strings.Cut(dsn[i+1:], "?"); ok {
+		out += "?" + params
+	}
+	return out, nil
+}
+
+// PostgresCreateDatabase 创建 PostgreSQL 数据库，使用 opts 中的所有者与模板。
+// PostgreSQL 的 CREATE DATABASE 不支持 IF NOT EXISTS，因此先查询 pg_database 判断是否已存在。
+// 也可用于 OpenGauss 等兼容 PostgreSQL 协议的驱动。
+func PostgresCreateDatabase(ctx context.Context, db *sql.DB, name string, opts DatabaseOptions) error {
+	exists, err := postgresDatabaseExists(ctx, db, name)
+	if err != nil || exists {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, postgresCreateDatabaseSQL(name, opts)); err != nil {
+		// 并发创建时可能已被其他调用者创建
+		if exists, _ := postgresDatabaseExists(ctx, db, name); exists {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// PostgresDropDatabase 通过 DROP DATABASE IF EXISTS 删除 PostgreSQL 数据库。
+func PostgresDropDatabase(ctx context.Context, db *sql.DB, name string) error {
+	_, err := db.ExecContext(ctx, "DROP DATABASE IF EXISTS "+quoteIdent(name, '"'))
+	return err
+}
+
+// PostgresDatabaseName 从 PostgreSQL DSN 中解析数据库名，支持 "host=... dbname=..." 与 "postgres://.../dbname" 两种形式。
+func PostgresDatabaseName(dsn string) string {
+	if isPostgresURL(dsn) {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return ""
+		}
+		if name := u.Query().Get("dbname"); name != "" {
+			return name
+		}
+		return strings.TrimPrefix(u.Path, "/")
+	}
+	for _, field := range strings.Fields(dsn) {
+		if name, ok := strings.CutPrefix(field, "dbname="); ok {
+			return strings.Trim(name, "'")
+		}
+	}
+	return ""
+}
+
+// PostgresSetDatabase 把 PostgreSQL DSN 中的数据库替换为 name，支持 "host=... dbname=..." 与 "postgres://.../dbname" 两种形式。
+func PostgresSetDatabase(dsn, name string) (string, error) {
+	if isPostgresURL(dsn) {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.Path = "/" + name
+		if q := u.Query(); q.Has("dbname") {
+			q.Set("dbname", name)
+			u.RawQuery = q.Encode()
+		}
+		return u.String(), nil
+	}
+	return setDSNKey(dsn, "dbname", name), nil
+}
+
+// PostgresSetSearchPath 在 PostgreSQL DSN 中设置 search_path，已设置时替换。
+func PostgresSetSearchPath(dsn, schema string) (string, error) {
+	if isPostgresURL(dsn) {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+	return setDSNKey(dsn, "search_path", schema), nil
+}
+
+// isPostgresURL 判断 PostgreSQL DSN 是否为 URL 形式
+func isPostgresURL(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// setDSNKey 设置 "key=value ..." 形式 DSN 中 key 的值，不存在时追加；值为空或包含空格、引号时加单引号
+func setDSNKey(dsn, key, value string) string {
+	if value == "" || strings.ContainsAny(value, " '\\") {
+		value = "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+	}
+	pattern := regexp.MustCompile(`(^|\s)` + regexp.QuoteMeta(key) + `=('(?:[^'\\]|\\.)*'|\S*)`)
+	loc := pattern.FindStringSubmatchIndex(dsn)
+	if loc == nil {
+		return strings.TrimSpace(dsn + " " + key + "=" + value)
+	}
+	return dsn[:loc[3]] + key + "=" + value + dsn[loc[1]:]
+}
+
+// postgresDatabaseExists 判断 PostgreSQL 数据库是否存在
+func postgresDatabaseExists(ctx context.Context, db *sql.DB, name string) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT count(*) FROM pg_database WHERE datname = $1", name).Scan(&n)
+	return n > 0, err
+}
+
+// postgresCreateDatabaseSQL 生成 PostgreSQL 的建库语句
+func postgresCreateDatabaseSQL(name string, opts DatabaseOptions) string {
+	stmt := "CREATE DATABASE " + quoteIdent(name, '"')
+	if opts.Owner != "" {
+		stmt += " OWNER " + quoteIdent(opts.Owner, '"')
+	}
+	if opts.Template != "" {
+		stmt += " TEMPLATE " + quoteIdent(opts.Template, '"')
+	}
+	return stmt
+}
+
+// SQLiteCreateDatabase 创建 SQLite 数据库文件及其所在目录，name 为文件路径，"?" 之后的连接参数会被忽略；
+// 内存数据库与 URI 形式的 name 不做任何处理。
+func SQLiteCreateDatabase(ctx context.Context, db *sql.DB, name string, opts DatabaseOptions) error {
+	path, ok := sqliteFile(name)
+	if !ok {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// SQLiteDropDatabase 删除 SQLite 数据库文件及其 -wal、-shm、-journal 文件，"?" 之后的连接参数会被忽略；
+// 内存数据库与 URI 形式的 name 不做任何处理。
+func SQLiteDropDatabase(ctx context.Context, db *sql.DB, name string) error {
+	file, ok := sqliteFile(name)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, path := range []string{file, file + "-wal", file + "-shm", file + "-journal"} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SQLiteDatabaseName 从 SQLite DSN 中解析数据库文件的绝对路径，内存数据库与 "file:" URI 形式的 DSN 返回空字符串。
+func SQLiteDatabaseName(dsn string) string {
+	path, ok := sqliteFile(dsn)
+	if !ok {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// SQLiteSetDatabase 把 SQLite DSN 中的文件路径替换为 name，保留 "file:" 前缀与 "?" 之后的连接参数（name 自带参数时除外）。
+func SQLiteSetDatabase(dsn, name string) (string, error) {
+	prefix, rest := "", dsn
+	if strings.HasPrefix(dsn, "file:") && !strings.HasPrefix(name, "file:") {
+		prefix, rest = "file:", strings.TrimPrefix(dsn, "file:")
+	}
+	if _, params, ok := strings.Cut(rest, "?"); ok && !strings.Contains(name, "?") {
+		name += "?" + params
+	}
+	return prefix + name, nil
+}
+
+// sqliteFile 返回 SQLite DSN 去掉 "?" 之后的连接参数后的文件路径，内存数据库与 "file:" URI 形式的 DSN 不对应文件
+func sqliteFile(dsn string) (string, bool) {
+	path, _, _ := strings.Cut(dsn, "?")
+	if path == "" || path == ":memory:" || strings.HasPrefix(path, "file:") {
+		return "", false
+	}
+	return path, true
+}
+
+// SQLServerDatabaseName 从 SQL Server DSN 中解析数据库名，支持 "sqlserver://...?database=..." 与
+// "server=...;database=..." 两种形式。
+func SQLServerDatabaseName(dsn string) string {
+	if strings.HasPrefix(dsn, "sqlserver://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return ""
+		}
+		return u.Query().Get("database")
+	}
+	for _, part := range strings.Split(dsn, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "database", "initial catalog":
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// SQLServerSetDatabase 把 SQL Server DSN 中的数据库替换为 name，支持 "sqlserver://...?database=..." 与
+// "server=...;database=..." 两种形式。
+func SQLServerSetDatabase(dsn, name string) (string, error) {
+	if strings.HasPrefix(dsn, "sqlserver://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		q := u.Query()
+		q.Set("database", name)
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+	if strings.ContainsAny(name, ";=") {
+		return "", fmt.Errorf("invalid SQL Server database name %q", name)
+	}
+	parts := strings.Split(dsn, ";")
+	for i, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "database", "initial catalog":
+			parts[i] = key + "=" + name
+			return strings.Join(parts, ";"), nil
+		}
+	}
+	return strings.TrimSuffix(dsn, ";") + ";database=" + name, nil
+}
+
+// quoteIdent 用 quote 包围标识符，标识符中的 quote 字符转义为两个
+func quoteIdent(name string, quote byte) string {
+	q := string(quote)
+	return q + strings.ReplaceAll(name, q, q+q) + q
+}
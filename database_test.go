@@ -0,0 +1,178 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+)
+
+// TestRegisterToNewDB 测试创建 SQLite 数据库文件后注册，以及确认后删除
+func TestRegisterToNewDB(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "tpl", DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, "tpl.db")})
+
+	dbName := filepath.Join(dir, "tenants", "acme.db")
+	isNew, err := RegisterToNewDB(ctx, group, "tpl", "acme", dbName, DatabaseOptions{})
+	if err != nil || !isNew {
+		t.Fatalf("RegisterToNewDB() = %v, %v", isNew, err)
+	}
+	if _, err := os.Stat(dbName); err != nil {
+		t.Fatalf("应创建数据库文件: %v", err)
+	}
+	// 已存在时不做处理
+	if err := CreateDB(ctx, group, "tpl", dbName, DatabaseOptions{}); err != nil {
+		t.Errorf("数据库已存在时 CreateDB() 不应返回错误: %v", err)
+	}
+	if err := group.MustGet(ctx, "acme").Exec("CREATE TABLE t (id INTEGER)").Error; err != nil {
+		t.Fatalf("新数据库不可用: %v", err)
+	}
+
+	if err := DropDB(ctx, group, "tpl", dbName, false); !errors.Is(err, ErrDropDBNotConfirmed) {
+		t.Errorf("未确认时应返回 ErrDropDBNotConfirmed，实际为: %v", err)
+	}
+	if err := DropDB(ctx, group, "tpl", dbName, true); err == nil {
+		t.Error("数据库仍被连接使用时应返回错误")
+	}
+
+	group.Unregister(ctx, "acme")
+	if err := DropDB(ctx, group, "tpl", dbName, true); err != nil {
+		t.Fatalf("DropDB() 失败: %v", err)
+	}
+	if _, err := os.Stat(dbName); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("数据库文件应被删除: %v", err)
+	}
+	// 不存在时不做处理
+	if err := DropDB(ctx, group, "tpl", dbName, true); err != nil {
+		t.Errorf("数据库不存在时 DropDB() 不应返回错误: %v", err)
+	}
+}
+
+// TestCreateDB_Unsupported 测试驱动未提供建库函数时返回 ErrDatabaseUnsupported
+func TestCreateDB_Unsupported(t *testing.T) {
+	ctx := context.Background()
+	RegisterDriver("nodb_sqlite", DriverSpec{Dialector: sqlite.Open, DSN: SQLiteDSN})
+	t.Cleanup(func() { unregisterDriver("nodb_sqlite") })
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "custom", DBConfig{DriverType: "nodb_sqlite", DBName: ":memory:"})
+
+	if err := CreateDB(ctx, group, "custom", "other", DatabaseOptions{}); !errors.Is(err, ErrDatabaseUnsupported) {
+		t.Errorf("应返回 ErrDatabaseUnsupported，实际为: %v", err)
+	}
+}
+
+// TestCreateDatabaseSQL 测试 MySQL 与 PostgreSQL 的建库语句
+func TestCreateDatabaseSQL(t *testing.T) {
+	stmt, err := mysqlCreateDatabaseSQL("order`db", DatabaseOptions{Collation: "utf8mb4_general_ci"})
+	if err != nil {
+		t.Fatalf("mysqlCreateDatabaseSQL() 失败: %v", err)
+	}
+	if want := "CREATE DATABASE IF NOT EXISTS `order``db` CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci"; stmt != want {
+		t.Errorf("MySQL 建库语句 = %q, 期望 %q", stmt, want)
+	}
+	if _, err := mysqlCreateDatabaseSQL("db", DatabaseOptions{Charset: "utf8; DROP"}); err == nil {
+		t.Error("非法的字符集应返回错误")
+	}
+
+	stmt = postgresCreateDatabaseSQL("tenant_a", DatabaseOptions{Owner: "app", Template: "template0"})
+	if want := `CREATE DATABASE "tenant_a" OWNER "app" TEMPLATE "template0"`; stmt != want {
+		t.Errorf("PostgreSQL 建库语句 = %q, 期望 %q", stmt, want)
+	}
+}
+
+// TestDropDB_InUseByDSN 测试数据库被其他组中显式设置了 DSN 的连接使用时不能删除，以及建库时忽略 SQLite 的连接参数
+func TestDropDB_InUseByDSN(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	m := NewManager()
+	defer m.Close(ctx)
+	m.AddGroup("admin")
+	m.AddGroup("business")
+	admin := m.MustGroup("admin")
+	admin.Register(ctx, "tpl", DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, "tpl.db")})
+
+	dbName := filepath.Join(dir, "order.db")
+	if err := CreateDB(ctx, admin, "tpl", dbName+"?_busy_timeout=1000", DatabaseOptions{}); err != nil {
+		t.Fatalf("CreateDB() 失败: %v", err)
+	}
+	if _, err := os.Stat(dbName); err != nil {
+		t.Fatalf("应创建不含连接参数的数据库文件: %v", err)
+	}
+
+	business := m.MustGroup("business")
+	business.Register(ctx, "order", DBConfig{DriverType: "sqlite", DSN: dbName + "?_busy_timeout=1000"})
+	err := DropDB(ctx, admin, "tpl", dbName, true)
+	if err == nil || !strings.Contains(err.Error(), "business/order") {
+		t.Fatalf("数据库仍被其他组的连接使用时应返回错误，实际为: %v", err)
+	}
+
+	business.Unregister(ctx, "order")
+	if err := DropDB(ctx, admin, "tpl", dbName, true); err != nil {
+		t.Fatalf("DropDB() 失败: %v", err)
+	}
+	if _, err := os.Stat(dbName); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("数据库文件应被删除: %v", err)
+	}
+}
+
+// TestDatabaseName 测试从各驱动的 DSN 中解析数据库名
+func TestDatabaseName(t *testing.T) {
+	abs, _ := filepath.Abs("data/order.db")
+	tests := []struct {
+		name  string
+		parse func(string) string
+		dsn   string
+		want  string
+	}{
+		{"mysql", MySQLDatabaseName, "root:p/w@tcp(127.0.0.1:3306)/order?charset=utf8mb4", "order"},
+		{"mysql 无数据库", MySQLDatabaseName, "root@tcp(127.0.0.1:3306)/", ""},
+		{"postgres", PostgresDatabaseName, "host=127.0.0.1 user=app dbname=order sslmode=disable", "order"},
+		{"postgres url", PostgresDatabaseName, "postgres://app:pw@127.0.0.1:5432/order?sslmode=disable", "order"},
+		{"sqlite", SQLiteDatabaseName, "./data/order.db?_busy_timeout=1000", abs},
+		{"sqlite 内存", SQLiteDatabaseName, ":memory:", ""},
+		{"sqlserver url", SQLServerDatabaseName, "sqlserver://sa:pw@127.0.0.1:1433?database=order", "order"},
+		{"sqlserver ado", SQLServerDatabaseName, "server=127.0.0.1;user id=sa;Initial Catalog=order", "order"},
+	}
+	for _, tt := range tests {
+		if got := tt.parse(tt.dsn); got != tt.want {
+			t.Errorf("%s: 解析 %q = %q, 期望 %q", tt.name, tt.dsn, got, tt.want)
+		}
+	}
+}
+
+// TestSetDatabase 测试替换各驱动 DSN 中的数据库，其他部分保持不变
+func TestSetDatabase(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(string, string) (string, error)
+		dsn  string
+		want string
+	}{
+		{"mysql", MySQLSetDatabase, "root:p/w@tcp(127.0.0.1:3306)/app?charset=utf8mb4", "root:p/w@tcp(127.0.0.1:3306)/order?charset=utf8mb4"},
+		{"mysql 无参数", MySQLSetDatabase, "root@tcp(127.0.0.1:3306)/app", "root@tcp(127.0.0.1:3306)/order"},
+		{"postgres", PostgresSetDatabase, "host=127.0.0.1 dbname=app sslmode=disable", "host=127.0.0.1 dbname=order sslmode=disable"},
+		{"postgres 无 dbname", PostgresSetDatabase, "host=127.0.0.1", "host=127.0.0.1 dbname=order"},
+		{"postgres url", PostgresSetDatabase, "postgres://app:pw@127.0.0.1:5432/app?sslmode=disable", "postgres://app:pw@127.0.0.1:5432/order?sslmode=disable"},
+		{"sqlite", SQLiteSetDatabase, "data/app.db?_busy_timeout=1000", "order?_busy_timeout=1000"},
+		{"sqlite uri", SQLiteSetDatabase, "file:app.db?cache=shared", "file:order?cache=shared"},
+		{"sqlserver url", SQLServerSetDatabase, "sqlserver://sa:pw@127.0.0.1:1433?database=app", "sqlserver://sa:pw@127.0.0.1:1433?database=order"},
+		{"sqlserver ado", SQLServerSetDatabase, "server=127.0.0.1;Initial Catalog=app;user id=sa", "server=127.0.0.1;Initial Catalog=order;user id=sa"},
+	}
+	for _, tt := range tests {
+		if got, err := tt.set(tt.dsn, "order"); err != nil || got != tt.want {
+			t.Errorf("%s: 替换 %q = %q, %v, 期望 %q", tt.name, tt.dsn, got, err, tt.want)
+		}
+	}
+	if _, err := MySQLSetDatabase("root@tcp(127.0.0.1:3306)", "order"); err == nil {
+		t.Error("MySQL DSN 缺少 /dbname 时应返回错误")
+	}
+}
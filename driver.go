@@ -0,0 +1,117 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// DriverSpec 描述一种可通过 DriverType 使用的数据库驱动。
+//
+// 通过 RegisterDriver 注册后，CreateDialector、AutoDsn 与 Validate 都会使用该描述，
+// 从而无需修改 mgorm 即可接入 ClickHouse、TiDB、OpenGauss 或自定义包装的驱动。
+type DriverSpec struct {
+	// Dialector 根据 DSN 创建 gorm.Dialector（必需）
+	Dialector func(dsn string) gorm.Dialector
+	// DSN 根据 Host、Port 等字段生成 DSN（可选，为 nil 时该驱动只能使用显式 DSN）
+	DSN func(cfg *DBConfig) string
+	// RequiredFields 根据字段生成 DSN 时必须提供的字段，使用配置文件中的 key（如 host、db_name）
+	RequiredFields []string
+	// IsRetryable 判断打开连接失败的错误是否可重试（可选，为 nil 时使用 IsRetryableError），
+	// 用于区分数据库尚未启动等临时错误与认证失败等不会自行恢复的错误
+	IsRetryable func(err error) bool
+	// CreateDatabase 在 db 所连接的服务器上创建名为 name 的数据库，已存在时不做处理（可选，为 nil 时 CreateDB 返回 ErrDatabaseUnsupported）
+	CreateDatabase func(ctx context.Context, db *sql.DB, name string, opts DatabaseOptions) error
+	// DropDatabase 删除名为 name 的数据库，不存在时不做处理（可选，为 nil 时 DropDB 返回 ErrDatabaseUnsupported）
+	DropDatabase func(ctx context.Context, db *sql.DB, name string) error
+	// DatabaseName 从 DSN 中解析出连接的数据库名（可选，为 nil 时使用 DBName），
+	// DropDB 据此判断数据库是否仍被显式设置了 DSN 的连接使用
+	DatabaseName func(dsn string) string
+	// SetDatabase 返回把 dsn 中的数据库替换为 name 的 DSN（可选），RegisterToDB 等复制显式设置了 DSN 的连接时使用；
+	// 为 nil 时这类连接不能复制到其他数据库
+	SetDatabase func(dsn, name string) (string, error)
+	// SetSearchPath 返回在 dsn 中设置 search_path 为 schema 的 DSN（可选），为 nil 时 RegisterToSchema 不支持独立连接池的 Schema
+	SetSearchPath func(dsn, schema string) (string, error)
+	// LiteralDSN DSN 中的 "file:" 等前缀属于驱动自身的语法，显式设置的 DSN 不作为密钥引用解析（如 SQLite 的 "file:test.db?cache=shared"）
+	LiteralDSN bool
+}
+
+// driverRegistry 已注册的驱动，key 为 DriverType
+var driverRegistry = struct {
+	sync.RWMutex
+	specs map[string]DriverSpec
+}{
+	specs: make(map[string]DriverSpec),
+}
+
+// RegisterDriver 以 name 作为 DriverType 注册驱动。
+// 重复注册同一 name 会覆盖之前的描述，可用于替换内置驱动。
+// name 为空或 spec.Dialector 为 nil 时 panic。
+func RegisterDriver(name string, spec DriverSpec) {
+	if name == "" {
+		panic("mgorm: RegisterDriver driver name is empty")
+	}
+	if spec.Dialector == nil {
+		panic("mgorm: RegisterDriver Dialector is nil for driver " + name)
+	}
+
+	driverRegistry.Lock()
+	defer driverRegistry.Unlock()
+	driverRegistry.specs[name] = spec
+}
+
+// Drivers 返回已注册的驱动名称，按字典序排列。
+func Drivers() []string {
+	driverRegistry.RLock()
+	defer driverRegistry.RUnlock()
+	return sortedKeys(driverRegistry.specs)
+}
+
+// lookupDriver 查找已注册的驱动
+func lookupDriver(name string) (DriverSpec, bool) {
+	driverRegistry.RLock()
+	defer driverRegistry.RUnlock()
+	spec, ok := driverRegistry.specs[name]
+	return spec, ok
+}
+
+// newErrUnknownDriverType 创建未知驱动类型错误，错误信息中列出已注册的驱动
+func newErrUnknownDriverType(driverType string) error {
+	return fmt.Errorf("%w: %q (registered: %s)", ErrUnknownDriverType, driverType, strings.Join(Drivers(), ", "))
+}
+
+// MySQLDSN 根据字段生成 MySQL DSN，Charset 为空时设置为 utf8mb4。
+// 也可用于 TiDB 等兼容 MySQL 协议的驱动。
+func MySQLDSN(c *DBConfig) string {
+	if c.Charset == "" {
+		c.Charset = "utf8mb4"
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
+		c.User, c.Password, c.Host, c.Port, c.DBName, c.Charset)
+}
+
+// PostgresDSN 根据字段生成 PostgreSQL DSN。
+// 也可用于 OpenGauss 等兼容 PostgreSQL 协议的驱动。
+func PostgresDSN(c *DBConfig) string {
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		c.Host, c.Port, c.User, c.Password, c.DBName)
+	if c.SearchPath != "" {
+		dsn += " search_path=" + c.SearchPath
+	}
+	return dsn
+}
+
+// SQLiteDSN 根据字段生成 SQLite DSN，直接使用 DBName 作为文件路径或 ":memory:"。
+func SQLiteDSN(c *DBConfig) string {
+	return c.DBName
+}
+
+// SQLServerDSN 根据字段生成 SQL Server DSN。
+func SQLServerDSN(c *DBConfig) string {
+	return fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s",
+		c.User, c.Password, c.Host, c.Port, c.DBName)
+}
@@ -0,0 +1,55 @@
+// Package mysql 将 MySQL 驱动注册到 mgorm，DriverType 为 "mysql"。
+//
+// mgorm 根包不依赖任何数据库驱动，需要使用 MySQL 时以空白导入方式引入本包：
+//
+//	import _ "github.com/qq1060656096/mgorm/driver/mysql"
+package mysql
+
+import (
+	"errors"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/qq1060656096/mgorm"
+	gormmysql "gorm.io/driver/mysql"
+)
+
+// Name 是本驱动在 mgorm 中注册的 DriverType
+const Name = "mysql"
+
+func init() {
+	mgorm.RegisterDriver(Name, mgorm.DriverSpec{
+		Dialector:      gormmysql.Open,
+		DSN:            mgorm.MySQLDSN,
+		RequiredFields: []string{"host"},
+		IsRetryable:    IsRetryable,
+		CreateDatabase: mgorm.MySQLCreateDatabase,
+		DropDatabase:   mgorm.MySQLDropDatabase,
+		DatabaseName:   mgorm.MySQLDatabaseName,
+		SetDatabase:    mgorm.MySQLSetDatabase,
+	})
+}
+
+// 可重试的 MySQL 服务端错误码
+const (
+	erConCount         = 1040 // ER_CON_COUNT_ERROR: Too many connections
+	erServerShutdown   = 1053 // ER_SERVER_SHUTDOWN: Server shutdown in progress
+	erTooManyUserConns = 1203 // ER_TOO_MANY_USER_CONNECTIONS
+)
+
+// IsRetryable 判断打开 MySQL 连接失败的错误是否可重试。
+// 连接数已满、服务端正在关闭等错误可重试，认证失败（1045）、数据库不存在（1049）等服务端错误不可重试，
+// 其他错误交给 mgorm.IsRetryableError 判断。
+func IsRetryable(err error) bool {
+	var mysqlErr *gomysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case erConCount, erServerShutdown, erTooManyUserConns:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, gomysql.ErrInvalidConn) {
+		return true
+	}
+	return mgorm.IsRetryableError(err)
+}
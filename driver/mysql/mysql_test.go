@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/qq1060656096/mgorm"
+)
+
+// TestRegister 测试导入本包后驱动已注册到 mgorm
+func TestRegister(t *testing.T) {
+	dialector, err := mgorm.CreateDialector(Name, "")
+	if err != nil {
+		t.Fatalf("CreateDialector() 失败: %v", err)
+	}
+	if dialector.Name() != Name {
+		t.Errorf("Dialector.Name() = %q, 期望 %q", dialector.Name(), Name)
+	}
+
+	config := mgorm.DBConfig{DriverType: Name, Host: "localhost", Port: 1, User: "u", Password: "p", DBName: "db"}
+	if config.AutoDsn() == "" {
+		t.Error("AutoDsn() 不应为空")
+	}
+	if err := (&mgorm.DBConfig{DriverType: Name}).Validate(); !mgorm.IsErrNoDSN(err) {
+		t.Errorf("缺少 host 时应返回 NoDSN 错误，实际为: %v", err)
+	}
+}
+
+// TestIsRetryable 测试 MySQL 错误的重试判断
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"too many connections", &gomysql.MySQLError{Number: 1040}, true},
+		{"access denied", &gomysql.MySQLError{Number: 1045}, false},
+		{"unknown database", fmt.Errorf("open: %w", &gomysql.MySQLError{Number: 1049}), false},
+		{"invalid conn", gomysql.ErrInvalidConn, true},
+		{"connection refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, 期望 %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
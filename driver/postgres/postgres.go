@@ -0,0 +1,44 @@
+// Package postgres 将 PostgreSQL 驱动注册到 mgorm，DriverType 为 "postgres"。
+//
+// mgorm 根包不依赖任何数据库驱动，需要使用 PostgreSQL 时以空白导入方式引入本包：
+//
+//	import _ "github.com/qq1060656096/mgorm/driver/postgres"
+package postgres
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/qq1060656096/mgorm"
+	gormpostgres "gorm.io/driver/postgres"
+)
+
+// Name 是本驱动在 mgorm 中注册的 DriverType
+const Name = "postgres"
+
+func init() {
+	mgorm.RegisterDriver(Name, mgorm.DriverSpec{
+		Dialector:      gormpostgres.Open,
+		DSN:            mgorm.PostgresDSN,
+		RequiredFields: []string{"host"},
+		IsRetryable:    IsRetryable,
+		CreateDatabase: mgorm.PostgresCreateDatabase,
+		DropDatabase:   mgorm.PostgresDropDatabase,
+		DatabaseName:   mgorm.PostgresDatabaseName,
+		SetDatabase:    mgorm.PostgresSetDatabase,
+		SetSearchPath:  mgorm.PostgresSetSearchPath,
+	})
+}
+
+// IsRetryable 判断打开 PostgreSQL 连接失败的错误是否可重试。
+// 连接异常（SQLSTATE 08 类）、数据库正在启动（57P03）、连接数已满（53300）可重试，
+// 认证失败（28P01）、数据库不存在（3D000）等服务端错误不可重试，
+// 其他错误交给 mgorm.IsRetryableError 判断。
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P03" || pgErr.Code == "53300"
+	}
+	return mgorm.IsRetryableError(err)
+}
@@ -0,0 +1,29 @@
+// Package sqlite 将 SQLite 驱动注册到 mgorm，DriverType 为 "sqlite"。
+//
+// mgorm 根包不依赖任何数据库驱动，需要使用 SQLite 时以空白导入方式引入本包：
+//
+//	import _ "github.com/qq1060656096/mgorm/driver/sqlite"
+//
+// 注意：gorm.io/driver/sqlite 依赖 cgo，不使用 SQLite 的服务不要导入本包，以保持无 cgo 构建。
+package sqlite
+
+import (
+	"github.com/qq1060656096/mgorm"
+	gormsqlite "gorm.io/driver/sqlite"
+)
+
+// Name 是本驱动在 mgorm 中注册的 DriverType
+const Name = "sqlite"
+
+func init() {
+	mgorm.RegisterDriver(Name, mgorm.DriverSpec{
+		Dialector:      gormsqlite.Open,
+		DSN:            mgorm.SQLiteDSN,
+		RequiredFields: []string{"db_name"},
+		CreateDatabase: mgorm.SQLiteCreateDatabase,
+		DropDatabase:   mgorm.SQLiteDropDatabase,
+		DatabaseName:   mgorm.SQLiteDatabaseName,
+		SetDatabase:    mgorm.SQLiteSetDatabase,
+		LiteralDSN:     true,
+	})
+}
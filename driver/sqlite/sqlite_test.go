@@ -0,0 +1,28 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qq1060656096/mgorm"
+)
+
+// TestRegister 测试导入本包后可以仅通过 DriverType 打开 SQLite 连接
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	group := mgorm.New()
+	defer group.Close(ctx)
+
+	_, err := group.Register(ctx, "memory", mgorm.DBConfig{DriverType: Name, DBName: ":memory:"})
+	if err != nil {
+		t.Fatalf("Register() 失败: %v", err)
+	}
+
+	db, err := group.Get(ctx, "memory")
+	if err != nil {
+		t.Fatalf("Get() 失败: %v", err)
+	}
+	if db.Dialector.Name() != Name {
+		t.Errorf("Dialector.Name() = %q, 期望 %q", db.Dialector.Name(), Name)
+	}
+}
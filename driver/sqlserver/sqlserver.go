@@ -0,0 +1,24 @@
+// Package sqlserver 将 SQL Server 驱动注册到 mgorm，DriverType 为 "sqlserver"。
+//
+// mgorm 根包不依赖任何数据库驱动，需要使用 SQL Server 时以空白导入方式引入本包：
+//
+//	import _ "github.com/qq1060656096/mgorm/driver/sqlserver"
+package sqlserver
+
+import (
+	"github.com/qq1060656096/mgorm"
+	gormsqlserver "gorm.io/driver/sqlserver"
+)
+
+// Name 是本驱动在 mgorm 中注册的 DriverType
+const Name = "sqlserver"
+
+func init() {
+	mgorm.RegisterDriver(Name, mgorm.DriverSpec{
+		Dialector:      gormsqlserver.Open,
+		DSN:            mgorm.SQLServerDSN,
+		RequiredFields: []string{"host"},
+		DatabaseName:   mgorm.SQLServerDatabaseName,
+		SetDatabase:    mgorm.SQLServerSetDatabase,
+	})
+}
@@ -0,0 +1,26 @@
+package sqlserver
+
+import (
+	"testing"
+
+	"github.com/qq1060656096/mgorm"
+)
+
+// TestRegister 测试导入本包后驱动已注册到 mgorm
+func TestRegister(t *testing.T) {
+	dialector, err := mgorm.CreateDialector(Name, "")
+	if err != nil {
+		t.Fatalf("CreateDialector() 失败: %v", err)
+	}
+	if dialector.Name() != Name {
+		t.Errorf("Dialector.Name() = %q, 期望 %q", dialector.Name(), Name)
+	}
+
+	config := mgorm.DBConfig{DriverType: Name, Host: "localhost", Port: 1, User: "u", Password: "p", DBName: "db"}
+	if config.AutoDsn() == "" {
+		t.Error("AutoDsn() 不应为空")
+	}
+	if err := (&mgorm.DBConfig{DriverType: Name}).Validate(); !mgorm.IsErrNoDSN(err) {
+		t.Errorf("缺少 host 时应返回 NoDSN 错误，实际为: %v", err)
+	}
+}
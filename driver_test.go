@@ -0,0 +1,124 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+)
+
+// 根包不依赖任何驱动，而包内测试无法导入 driver 子包（会产生循环导入），
+// 因此测试中按照 driver 子包的方式注册内置驱动。
+func init() {
+	RegisterDriver("mysql", DriverSpec{Dialector: mysql.Open, DSN: MySQLDSN, RequiredFields: []string{"host"},
+		CreateDatabase: MySQLCreateDatabase, DropDatabase: MySQLDropDatabase, DatabaseName: MySQLDatabaseName,
+		SetDatabase: MySQLSetDatabase})
+	RegisterDriver("postgres", DriverSpec{Dialector: postgres.Open, DSN: PostgresDSN, RequiredFields: []string{"host"},
+		CreateDatabase: PostgresCreateDatabase, DropDatabase: PostgresDropDatabase, DatabaseName: PostgresDatabaseName,
+		SetDatabase: PostgresSetDatabase, SetSearchPath: PostgresSetSearchPath})
+	RegisterDriver("sqlite", DriverSpec{Dialector: sqlite.Open, DSN: SQLiteDSN, RequiredFields: []string{"db_name"},
+		CreateDatabase: SQLiteCreateDatabase, DropDatabase: SQLiteDropDatabase, DatabaseName: SQLiteDatabaseName,
+		SetDatabase: SQLiteSetDatabase, LiteralDSN: true})
+	RegisterDriver("sqlserver", DriverSpec{Dialector: sqlserver.Open, DSN: SQLServerDSN, RequiredFields: []string{"host"},
+		DatabaseName: SQLServerDatabaseName, SetDatabase: SQLServerSetDatabase})
+}
+
+// TestDrivers_Builtin 测试内置驱动通过注册表注册（见本文件 init）
+func TestDrivers_Builtin(t *testing.T) {
+	names := strings.Join(Drivers(), ",")
+	for _, name := range []string{"mysql", "postgres", "sqlite", "sqlserver"} {
+		if !strings.Contains(names, name) {
+			t.Errorf("已注册驱动 %q 中应包含 %q", names, name)
+		}
+	}
+}
+
+// TestRegisterDriver_Custom 测试注册自定义驱动后 CreateDialector、AutoDsn、opener 均可使用
+func TestRegisterDriver_Custom(t *testing.T) {
+	ctx := context.Background()
+	RegisterDriver("custom_sqlite", DriverSpec{
+		Dialector: func(dsn string) gorm.Dialector {
+			return sqlite.Open(dsn)
+		},
+		DSN: func(cfg *DBConfig) string {
+			return "file:" + cfg.DBName + "?mode=memory"
+		},
+		RequiredFields: []string{"db_name"},
+	})
+	t.Cleanup(func() { unregisterDriver("custom_sqlite") })
+
+	config := DBConfig{DriverType: "custom_sqlite", DBName: "custom"}
+	if dsn := config.AutoDsn(); dsn != "file:custom?mode=memory" {
+		t.Errorf("AutoDsn() = %q", dsn)
+	}
+
+	dialector, err := CreateDialector("custom_sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("CreateDialector() 失败: %v", err)
+	}
+	if dialector.Name() != "sqlite" {
+		t.Errorf("Dialector.Name() = %q, 期望 sqlite", dialector.Name())
+	}
+
+	db, err := opener(ctx, config)
+	if err != nil {
+		t.Fatalf("opener() 失败: %v", err)
+	}
+	closer(ctx, db)
+
+	if err := (&DBConfig{DriverType: "custom_sqlite"}).Validate(); !IsErrNoDSN(err) {
+		t.Errorf("缺少 db_name 时应返回 NoDSN 错误，实际为: %v", err)
+	}
+}
+
+// TestRegisterDriver_Panic 测试注册无效驱动时 panic
+func TestRegisterDriver_Panic(t *testing.T) {
+	tests := []struct {
+		name       string
+		driverName string
+		spec       DriverSpec
+	}{
+		{name: "空名称", driverName: "", spec: DriverSpec{Dialector: sqlite.Open}},
+		{name: "缺少 Dialector", driverName: "nil_dialector", spec: DriverSpec{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterDriver 应 panic")
+				}
+			}()
+			RegisterDriver(tt.driverName, tt.spec)
+		})
+	}
+}
+
+// TestCreateDialector_UnknownDriverType 测试未知驱动的错误信息列出已注册驱动
+func TestCreateDialector_UnknownDriverType(t *testing.T) {
+	_, err := CreateDialector("oracle", "dsn")
+	if !errors.Is(err, ErrUnknownDriverType) {
+		t.Fatalf("错误应为 ErrUnknownDriverType，实际为: %v", err)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "oracle") || !strings.Contains(msg, "mysql") || !strings.Contains(msg, "sqlite") {
+		t.Errorf("错误信息应包含驱动类型与已注册驱动，实际为: %v", msg)
+	}
+
+	if err := (&DBConfig{DriverType: "oracle", DSN: "dsn"}).Validate(); !errors.Is(err, ErrUnknownDriverType) {
+		t.Errorf("Validate() 应返回 ErrUnknownDriverType，实际为: %v", err)
+	}
+}
+
+// unregisterDriver 移除已注册的驱动，仅用于测试清理
+func unregisterDriver(name string) {
+	driverRegistry.Lock()
+	defer driverRegistry.Unlock()
+	delete(driverRegistry.specs, name)
+}
@@ -10,6 +10,14 @@ var (
 	errNoDSN = errors.New("mgorm: DSN is required when Dialector is not provided")
 	// errNoDialector 表示需要提供 Dialector 或导入相应的驱动包
 	errNoDialector = errors.New("mgorm: please provide a Dialector in DBConfig, or import the appropriate driver package")
+	// errNoDriverType 表示未提供 Dialector 时缺少 DriverType，无法创建 Dialector
+	errNoDriverType = errors.New("mgorm: DriverType is required when Dialector is not provided")
+	// errInvalidPool 表示连接池参数无效
+	errInvalidPool = errors.New("mgorm: invalid connection pool config")
+	// errInvalidRetry 表示连接重试参数无效
+	errInvalidRetry = errors.New("mgorm: invalid retry config")
+	// errInvalidReplica 表示读写分离配置无效
+	errInvalidReplica = errors.New("mgorm: invalid replica config")
 )
 
 // IsErrNoDSN 检查错误是否为缺少 DSN 配置错误
@@ -21,3 +29,23 @@ func IsErrNoDSN(err error) bool {
 func IsErrNoDialector(err error) bool {
 	return errors.Is(err, errNoDialector)
 }
+
+// IsErrNoDriverType 检查错误是否为缺少 DriverType 错误
+func IsErrNoDriverType(err error) bool {
+	return errors.Is(err, errNoDriverType)
+}
+
+// IsErrInvalidPool 检查错误是否为连接池参数无效错误
+func IsErrInvalidPool(err error) bool {
+	return errors.Is(err, errInvalidPool)
+}
+
+// IsErrInvalidRetry 检查错误是否为连接重试参数无效错误
+func IsErrInvalidRetry(err error) bool {
+	return errors.Is(err, errInvalidRetry)
+}
+
+// IsErrInvalidReplica 检查错误是否为读写分离配置无效错误
+func IsErrInvalidReplica(err error) bool {
+	return errors.Is(err, errInvalidReplica)
+}
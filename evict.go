@@ -0,0 +1,241 @@
+package mgorm
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EvictionConfig 空闲连接淘汰配置
+type EvictionConfig struct {
+	// IdleTimeout 连接超过该时间未通过 Get 获取时被关闭，为 0 时不按空闲时间淘汰
+	IdleTimeout time.Duration
+	// MaxOpen 同时打开的连接数上限，超过时关闭最久未使用的连接，为 0 时不限制
+	MaxOpen int
+	// Interval 后台检查空闲连接的间隔，为 0 时使用 IdleTimeout 的一半
+	Interval time.Duration
+}
+
+// EvictingGroup 包装 Group，记录每个连接最近一次被获取的时间，
+// 关闭空闲超过 IdleTimeout 或超出 MaxOpen 上限的连接。
+//
+// 被淘汰的连接只关闭连接池，配置仍然保留，下次 Get 时透明地重新打开。
+// 淘汰按“最近一次 Get”判断是否空闲，调用者不应长期持有 Get 返回的 *gorm.DB，
+// 而应在每次使用时重新 Get。
+type EvictingGroup struct {
+	Group
+	cfg EvictionConfig
+
+	// evictMu 保证淘汰时的“注销 - 重新注册”对 Get 是原子的
+	evictMu sync.RWMutex
+
+	mu      sync.Mutex
+	lru     *list.List               // lru 已打开的连接，表头为最近使用
+	entries map[string]*list.Element // entries 连接名到 lru 元素的映射
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// lruEntry 已打开的连接
+type lruEntry struct {
+	name     string
+	lastUsed time.Time
+}
+
+// NewEvictingGroup 创建带空闲淘汰的 Group。设置了 IdleTimeout 时启动后台检查协程，Close 时停止。
+//
+// 淘汰只对通过返回的 EvictingGroup 获取的连接生效，应始终通过它访问 g。
+func NewEvictingGroup(g Group, cfg EvictionConfig) (*EvictingGroup, error) {
+	if cfg.IdleTimeout < 0 || cfg.MaxOpen < 0 || cfg.Interval < 0 {
+		return nil, fmt.Errorf("mgorm: eviction settings must not be negative")
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = max(cfg.IdleTimeout/2, time.Millisecond)
+	}
+
+	e := &EvictingGroup{
+		Group:   g,
+		cfg:     cfg,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if cfg.IdleTimeout > 0 {
+		go e.run()
+	} else {
+		close(e.done)
+	}
+	return e, nil
+}
+
+// Unwrap 返回被包装的 Group
+func (e *EvictingGroup) Unwrap() Group {
+	return e.Group
+}
+
+// Get 获取数据库连接（已被淘汰时重新打开），并记录为最近使用。
+// 设置了 MaxOpen 时，打开的连接数超出上限后关闭最久未使用的连接。
+func (e *EvictingGroup) Get(ctx context.Context, name string) (*gorm.DB, error) {
+	e.evictMu.RLock()
+	db, err := e.Group.Get(ctx, name)
+	e.evictMu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, victim := range e.touch(name) {
+		_ = e.Evict(ctx, victim)
+	}
+	return db, nil
+}
+
+// MustGet 获取数据库连接，失败时 panic。
+func (e *EvictingGroup) MustGet(ctx context.Context, name string) *gorm.DB {
+	db, err := e.Get(ctx, name)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// Unregister 注销连接并停止跟踪。与 Evict 互斥，并发的淘汰不会重新注册已注销的连接。
+func (e *EvictingGroup) Unregister(ctx context.Context, name string) error {
+	e.evictMu.Lock()
+	defer e.evictMu.Unlock()
+
+	e.untrack(name)
+	return e.Group.Unregister(ctx, name)
+}
+
+// Close 停止后台检查并关闭组内所有连接。
+func (e *EvictingGroup) Close(ctx context.Context) []error {
+	e.closeOnce.Do(func() { close(e.stop) })
+	<-e.done
+
+	e.mu.Lock()
+	e.lru.Init()
+	e.entries = make(map[string]*list.Element)
+	e.mu.Unlock()
+
+	return e.Group.Close(ctx)
+}
+
+// Opened 返回当前跟踪的已打开连接名，按最近使用排序
+func (e *EvictingGroup) Opened() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	names := make([]string, 0, e.lru.Len())
+	for el := e.lru.Front(); el != nil; el = el.Next() {
+		names = append(names, el.Value.(*lruEntry).name)
+	}
+	return names
+}
+
+// Evict 关闭指定连接的连接池并保留其配置，下次 Get 时重新打开。
+func (e *EvictingGroup) Evict(ctx context.Context, name string) error {
+	e.untrack(name)
+
+	e.evictMu.Lock()
+	defer e.evictMu.Unlock()
+	if g, ok := mgormGroup(e.Group); ok {
+		// 与 Rotate 互斥，避免用旧配置重新注册覆盖轮换后的配置
+		g.pools.swapMu.Lock()
+		defer g.pools.swapMu.Unlock()
+	}
+
+	cfg, err := e.Group.Config(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := e.Group.Unregister(ctx, name); err != nil {
+		return err
+	}
+	if _, err := e.Group.Register(ctx, name, cfg); err != nil {
+		return fmt.Errorf("mgorm: re-register evicted %s: %w", name, err)
+	}
+	return nil
+}
+
+// EvictIdle 关闭空闲超过 IdleTimeout 的连接，返回被关闭的连接数。IdleTimeout 为 0 时不做任何处理。
+func (e *EvictingGroup) EvictIdle(ctx context.Context) (int, error) {
+	if e.cfg.IdleTimeout <= 0 {
+		return 0, nil
+	}
+	deadline := time.Now().Add(-e.cfg.IdleTimeout)
+
+	var idle []string
+	e.mu.Lock()
+	for el := e.lru.Back(); el != nil; el = el.Prev() {
+		entry := el.Value.(*lruEntry)
+		if entry.lastUsed.After(deadline) {
+			break
+		}
+		idle = append(idle, entry.name)
+	}
+	e.mu.Unlock()
+
+	var errs []error
+	for _, name := range idle {
+		if err := e.Evict(ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return len(idle), errors.Join(errs...)
+}
+
+// touch 把 name 标记为最近使用，返回超出 MaxOpen 上限需要淘汰的连接名
+func (e *EvictingGroup) touch(name string) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if el, ok := e.entries[name]; ok {
+		el.Value.(*lruEntry).lastUsed = time.Now()
+		e.lru.MoveToFront(el)
+	} else {
+		e.entries[name] = e.lru.PushFront(&lruEntry{name: name, lastUsed: time.Now()})
+	}
+
+	if e.cfg.MaxOpen <= 0 {
+		return nil
+	}
+	var victims []string
+	for el := e.lru.Back(); e.lru.Len()-len(victims) > e.cfg.MaxOpen && el != nil; el = el.Prev() {
+		victims = append(victims, el.Value.(*lruEntry).name)
+	}
+	return victims
+}
+
+// untrack 停止跟踪 name
+func (e *EvictingGroup) untrack(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if el, ok := e.entries[name]; ok {
+		e.lru.Remove(el)
+		delete(e.entries, name)
+	}
+}
+
+// run 定期关闭空闲连接
+func (e *EvictingGroup) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			_, _ = e.EvictIdle(context.Background())
+		}
+	}
+}
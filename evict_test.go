@@ -0,0 +1,159 @@
+package mgorm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEvictingGroup 创建包含 names 个内存 SQLite 连接的 EvictingGroup
+func newEvictingGroup(t *testing.T, cfg EvictionConfig, names ...string) *EvictingGroup {
+	t.Helper()
+	ctx := context.Background()
+	g, err := NewEvictingGroup(New(), cfg)
+	if err != nil {
+		t.Fatalf("NewEvictingGroup() 失败: %v", err)
+	}
+	t.Cleanup(func() { g.Close(ctx) })
+	for _, name := range names {
+		g.Register(ctx, name, DBConfig{DriverType: "sqlite", DBName: ":memory:", MaxIdleConns: 3})
+	}
+	return g
+}
+
+// TestEvictingGroup_MaxOpen 测试超出上限时关闭最久未使用的连接，配置保留
+func TestEvictingGroup_MaxOpen(t *testing.T) {
+	ctx := context.Background()
+	g := newEvictingGroup(t, EvictionConfig{MaxOpen: 2}, "a", "b", "c")
+
+	first := g.MustGet(ctx, "a")
+	g.MustGet(ctx, "b")
+	g.MustGet(ctx, "a") // a 变为最近使用
+	g.MustGet(ctx, "c") // 超出上限，淘汰 b
+
+	if opened := strings.Join(g.Opened(), ","); opened != "c,a" {
+		t.Errorf("已打开连接 = %s, 期望 c,a", opened)
+	}
+	if _, ok := OpenedDB(g, "b"); ok {
+		t.Error("b 应已被关闭")
+	}
+	if db, ok := OpenedDB(g, "a"); !ok || db != first {
+		t.Error("a 不应被关闭")
+	}
+
+	// 配置保留，再次 Get 时重新打开
+	cfg, err := g.Config(ctx, "b")
+	if err != nil || cfg.MaxIdleConns != 3 {
+		t.Fatalf("被淘汰的连接应保留配置: %+v, %v", cfg, err)
+	}
+	if err := g.MustGet(ctx, "b").Exec("SELECT 1").Error; err != nil {
+		t.Fatalf("被淘汰的连接应能重新打开: %v", err)
+	}
+	// 重新打开 b 后 a 成为最久未使用的连接
+	if opened := strings.Join(g.Opened(), ","); opened != "b,c" {
+		t.Errorf("已打开连接 = %s, 期望 b,c", opened)
+	}
+}
+
+// TestEvictingGroup_IdleTimeout 测试后台关闭空闲连接
+func TestEvictingGroup_IdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	g := newEvictingGroup(t, EvictionConfig{IdleTimeout: 20 * time.Millisecond, Interval: 5 * time.Millisecond}, "a")
+
+	before := g.MustGet(ctx, "a")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(g.Opened()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("空闲连接应被后台关闭")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := OpenedDB(g, "a"); ok {
+		t.Error("空闲连接应已关闭")
+	}
+
+	after := g.MustGet(ctx, "a")
+	if after == before {
+		t.Error("空闲连接被关闭后 Get 应重新打开")
+	}
+	if err := after.Exec("SELECT 1").Error; err != nil {
+		t.Errorf("重新打开的连接不可用: %v", err)
+	}
+}
+
+// TestEvictingGroup_EvictIdle 测试手动淘汰与 Unregister
+func TestEvictingGroup_EvictIdle(t *testing.T) {
+	ctx := context.Background()
+	g := newEvictingGroup(t, EvictionConfig{IdleTimeout: time.Hour}, "a", "b")
+	g.MustGet(ctx, "a")
+	g.MustGet(ctx, "b")
+
+	if n, err := g.EvictIdle(ctx); n != 0 || err != nil {
+		t.Errorf("没有空闲连接时 EvictIdle() = %d, %v", n, err)
+	}
+
+	if err := g.Unregister(ctx, "b"); err != nil {
+		t.Fatalf("Unregister() 失败: %v", err)
+	}
+	if opened := strings.Join(g.Opened(), ","); opened != "a" {
+		t.Errorf("Unregister 后已打开连接 = %s, 期望 a", opened)
+	}
+	if _, err := g.Config(ctx, "b"); err == nil {
+		t.Error("Unregister 应删除配置")
+	}
+
+	if _, err := NewEvictingGroup(New(), EvictionConfig{MaxOpen: -1}); err == nil {
+		t.Error("负数参数应返回错误")
+	}
+}
+
+// TestEvictingGroup_UnregisterDuringEvict 测试并发的淘汰不会重新注册刚被注销的连接
+func TestEvictingGroup_UnregisterDuringEvict(t *testing.T) {
+	ctx := context.Background()
+	g := newEvictingGroup(t, EvictionConfig{})
+
+	for i := 0; i < 200; i++ {
+		g.Register(ctx, "db", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+		g.MustGet(ctx, "db")
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = g.Evict(ctx, "db")
+		}()
+		if err := g.Unregister(ctx, "db"); err != nil {
+			t.Fatalf("Unregister() 失败: %v", err)
+		}
+		<-done
+
+		if names := g.List(); len(names) != 0 {
+			t.Fatalf("注销的连接被淘汰重新注册: %v", names)
+		}
+	}
+}
+
+// TestEvictingGroup_Rotate 测试轮换 EvictingGroup 中的连接，以及轮换与淘汰并发时保留轮换后的配置
+func TestEvictingGroup_Rotate(t *testing.T) {
+	ctx := context.Background()
+	g := newEvictingGroup(t, EvictionConfig{}, "db")
+	g.MustGet(ctx, "db")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = g.Evict(ctx, "db")
+	}()
+	err := RotateWithOptions(ctx, g, "db", RotateOptions{Update: func(cfg *DBConfig) { cfg.MaxIdleConns = 7 }})
+	if err != nil {
+		t.Fatalf("RotateWithOptions() 失败: %v", err)
+	}
+	<-done
+
+	if cfg := g.MustConfig(ctx, "db"); cfg.MaxIdleConns != 7 {
+		t.Errorf("淘汰不应覆盖轮换后的配置，MaxIdleConns = %d", cfg.MaxIdleConns)
+	}
+	if err := g.MustGet(ctx, "db").Exec("SELECT 1").Error; err != nil {
+		t.Errorf("轮换后连接应可用: %v", err)
+	}
+}
@@ -4,11 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
-	"gorm.io/driver/mysql"
-	"gorm.io/driver/postgres"
-	"gorm.io/driver/sqlite"
-	"gorm.io/driver/sqlserver"
 	"gorm.io/gorm"
 )
 
@@ -20,20 +17,84 @@ func RegisterToDB(ctx context.Context, group Group, fromName, toName, toDBName s
 	if err != nil {
 		return false, err
 	}
-
-	cfg.Name = toName
-	cfg.DBName = toDBName
-	cfg.DSN = ""
-	cfg.DSN = cfg.AutoDsn()
-	dialector, err := CreateDialector(cfg.DriverType, cfg.DSN)
+	cfg, err = cfg.toDB(toName, toDBName)
 	if err != nil {
 		return false, err
 	}
-	cfg.Dialector = dialector
-
 	return group.Register(ctx, toName, cfg)
 }
 
+// toDB 返回以 c 为模板、名称为 toName、数据库为 toDBName 的配置，DSN 与 Dialector 重新生成。
+// c 显式设置了 DSN（不是根据字段生成的）时，通过驱动的 DriverSpec.SetDatabase 只替换 DSN 中的数据库，
+// 驱动不支持时返回错误。新数据库总是使用独立的连接池，即使 c 以 SharedPool 模式注册。
+func (c DBConfig) toDB(toName, toDBName string) (DBConfig, error) {
+	explicit := c.explicitDSN()
+	c.Name = toName
+	c.DBName = toDBName
+	c.sharedFrom = ""
+	if explicit {
+		dsn, err := c.setDatabaseInDSN(toDBName)
+		if err != nil {
+			return DBConfig{}, err
+		}
+		c.DSN = dsn
+	} else {
+		c.DSN = ""
+	}
+	dsn := c.AutoDsn()
+	dialector, err := CreateDialector(c.DriverType, dsn)
+	if err != nil {
+		return DBConfig{}, err
+	}
+	c.Dialector = nil
+	if c.resolveAtOpen() {
+		// 密钥引用在打开连接时才解析，由 opener 生成 DSN
+		return c, nil
+	}
+	c.DSN = dsn
+	c.Dialector = dialector
+	return c, nil
+}
+
+// explicitDSN 判断 c 是否显式设置了 DSN，而不是由 LoadManager 等根据字段生成
+func (c *DBConfig) explicitDSN() bool {
+	if c.DSN == "" {
+		return false
+	}
+	fields := *c
+	fields.DSN = ""
+	return c.DSN != fields.AutoDsn()
+}
+
+// setDatabaseInDSN 通过 DriverSpec.SetDatabase 把显式设置的 DSN 中的数据库替换为 name
+func (c *DBConfig) setDatabaseInDSN(name string) (string, error) {
+	spec, err := c.explicitDSNSpec()
+	if err != nil {
+		return "", err
+	}
+	return editDSN(c.DriverType, "database", spec.SetDatabase, c.DSN, name)
+}
+
+// explicitDSNSpec 返回修改显式设置的 DSN 所用的驱动，DSN 为密钥引用时无法修改，返回错误
+func (c *DBConfig) explicitDSNSpec() (DriverSpec, error) {
+	if !c.literalDSN() && IsSecretRef(c.DSN) {
+		return DriverSpec{}, errors.New("mgorm: cannot modify a DSN secret reference, configure host, db_name and other fields instead")
+	}
+	return c.databaseSpec()
+}
+
+// editDSN 使用驱动提供的 edit 把 dsn 中的 what 设置为 value，驱动不支持（edit 为 nil）时返回错误
+func editDSN(driverType, what string, edit func(dsn, value string) (string, error), dsn, value string) (string, error) {
+	if edit == nil {
+		return "", fmt.Errorf("mgorm: driver %q does not support setting the %s in its DSN", driverType, what)
+	}
+	dsn, err := edit(dsn, value)
+	if err != nil {
+		return "", fmt.Errorf("mgorm: set %s in DSN: %w", what, err)
+	}
+	return dsn, nil
+}
+
 // MustRegisterToDB 使用当前 Group 中已有名称 fromName 的配置，
 // 将其注册为新的名称 toName，并写入指定数据库 toDBName。
 // 返回值 isNew 表示 toName 是否为新注册。
@@ -65,20 +126,120 @@ func BatchMustRegisterToDB(ctx context.Context, group Group, fromName string, to
 	}
 }
 
-// ErrUnknownDriverType 当指定了不支持的数据库驱动类型时返回此错误。
+// BatchRegisterOptions BatchRegisterToDB 的选项
+type BatchRegisterOptions struct {
+	// Concurrency 同时注册（设置了 Open 时包括打开）的数量上限，0 或 1 时按 toName 的字典序依次进行
+	Concurrency int
+	// Open 注册后立即打开新注册的连接（包括 Ping），确认目标数据库可用；为 false 时与 RegisterToDB 一样惰性打开
+	Open bool
+	// Rollback 任一注册或打开失败时注销本次新注册的连接（同时关闭已打开的连接），使 Group 保持调用前的状态
+	Rollback bool
+}
+
+// BatchRegisterToDB 批量将一个来源 DB(fromName) 注册到同一个 Group 下的多个目标 DB，
+// 是 BatchMustRegisterToDB 不会 panic 的版本。
+//
+// 注册前先为所有目标生成并校验配置，任一目标无效时不注册任何连接；
+// 之后按 toName 的字典序注册，设置了 Concurrency 时并发注册。
+// 设置了 Open 时立即打开新注册的连接，打开失败的 toName 视为失败并被注销。
+// 返回本次新注册且仍保持注册的 toName（按字典序，已注册的 toName 不会被覆盖、打开，也不包含在内），
+// 以及合并后的错误，每个错误都以失败的 toName 开头。
+// 设置了 Rollback 且有注册或打开失败时，本次新注册的连接会被注销，返回的 toName 为空。
+func BatchRegisterToDB(ctx context.Context, group Group, fromName string, toNameDBMap map[string]string, opts BatchRegisterOptions) (registered []string, err error) {
+	from, err := group.Config(ctx, fromName)
+	if err != nil {
+		return nil, err
+	}
+
+	toNames := sortedKeys(toNameDBMap)
+	cfgs := make([]DBConfig, len(toNames))
+	var errs []error
+	for i, toName := range toNames {
+		var cfg DBConfig
+		var err error
+		switch {
+		case toName == "":
+			err = errors.New("empty name")
+		case toNameDBMap[toName] == "":
+			err = errors.New("empty database name")
+		default:
+			cfg, err = from.toDB(toName, toNameDBMap[toName])
+			if err == nil {
+				err = cfg.Validate()
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", toName, err))
+		}
+		cfgs[i] = cfg
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	isNew := make([]bool, len(toNames))
+	errs = make([]error, len(toNames))
+	register := func(i int) {
+		isNew[i], errs[i] = group.Register(ctx, toNames[i], cfgs[i])
+		if isNew[i] && opts.Open {
+			if _, errs[i] = group.Get(ctx, toNames[i]); errs[i] != nil {
+				if uerr := group.Unregister(ctx, toNames[i]); uerr != nil {
+					errs[i] = errors.Join(errs[i], fmt.Errorf("unregister: %w", uerr))
+				} else {
+					isNew[i] = false
+				}
+			}
+		}
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("%s: %w", toNames[i], errs[i])
+		}
+	}
+	if opts.Concurrency <= 1 {
+		for i := range toNames {
+			register(i)
+		}
+	} else {
+		sem := make(chan struct{}, opts.Concurrency)
+		var wg sync.WaitGroup
+		for i := range toNames {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer func() { <-sem; wg.Done() }()
+				register(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	for i, toName := range toNames {
+		if isNew[i] {
+			registered = append(registered, toName)
+		}
+	}
+	err = errors.Join(errs...)
+	if err == nil || !opts.Rollback {
+		return registered, err
+	}
+
+	var rollbackErrs []error
+	for _, toName := range registered {
+		if uerr := group.Unregister(ctx, toName); uerr != nil {
+			rollbackErrs = append(rollbackErrs, fmt.Errorf("rollback %s: %w", toName, uerr))
+		}
+	}
+	return nil, errors.Join(append([]error{err}, rollbackErrs...)...)
+}
+
+// ErrUnknownDriverType 当指定了未注册的数据库驱动类型时返回此错误。
 var ErrUnknownDriverType = errors.New("mgorm: unknown driver type")
 
+// CreateDialector 使用通过 RegisterDriver 注册的驱动，根据 dsn 创建 gorm.Dialector。
+// driverType 未注册时返回 ErrUnknownDriverType，错误信息中列出已注册的驱动。
 func CreateDialector(driverType, dsn string) (gorm.Dialector, error) {
-	switch driverType {
-	case "mysql":
-		return mysql.Open(dsn), nil
-	case "postgres":
-		return postgres.Open(dsn), nil
-	case "sqlite":
-		return sqlite.Open(dsn), nil
-	case "sqlserver":
-		return sqlserver.Open(dsn), nil
-	default:
-		return nil, fmt.Errorf("%w: %s", ErrUnknownDriverType, driverType)
+	spec, ok := lookupDriver(driverType)
+	if !ok {
+		return nil, newErrUnknownDriverType(driverType)
 	}
+	return spec.Dialector(dsn), nil
 }
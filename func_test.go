@@ -2,6 +2,9 @@ package mgorm
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -11,6 +14,7 @@ import (
 func TestRegisterToDB(t *testing.T) {
 	ctx := context.Background()
 	group := New()
+	targetDBName := filepath.Join(t.TempDir(), "target_db")
 
 	// 准备源配置
 	sourceConfig := DBConfig{
@@ -30,7 +34,7 @@ func TestRegisterToDB(t *testing.T) {
 	}
 
 	// 使用 RegisterToDB 注册目标数据库
-	isNew, err := RegisterToDB(ctx, group, "source", "target", "target_db")
+	isNew, err := RegisterToDB(ctx, group, "source", "target", targetDBName)
 	if err != nil {
 		t.Fatalf("RegisterToDB 失败: %v", err)
 	}
@@ -46,8 +50,8 @@ func TestRegisterToDB(t *testing.T) {
 	if targetConfig.Name != "target" {
 		t.Errorf("目标配置 Name = %q, 期望 %q", targetConfig.Name, "target")
 	}
-	if targetConfig.DBName != "target_db" {
-		t.Errorf("目标配置 DBName = %q, 期望 %q", targetConfig.DBName, "target_db")
+	if targetConfig.DBName != targetDBName {
+		t.Errorf("目标配置 DBName = %q, 期望 %q", targetConfig.DBName, targetDBName)
 	}
 
 	// 验证目标数据库连接可用
@@ -172,7 +176,7 @@ func TestMustRegisterToDB(t *testing.T) {
 	}
 
 	// 使用 MustRegisterToDB 注册目标数据库
-	isNew := MustRegisterToDB(ctx, group, "source", "target", "target_db")
+	isNew := MustRegisterToDB(ctx, group, "source", "target", filepath.Join(t.TempDir(), "target_db"))
 	if !isNew {
 		t.Error("应该是新注册的数据库")
 	}
@@ -243,6 +247,7 @@ func TestMustRegisterToDB_ExistingTarget(t *testing.T) {
 func TestBatchMustRegisterToDB(t *testing.T) {
 	ctx := context.Background()
 	group := New()
+	dir := t.TempDir()
 
 	// 注册源数据库
 	sourceConfig := DBConfig{
@@ -256,10 +261,10 @@ func TestBatchMustRegisterToDB(t *testing.T) {
 
 	// 批量注册目标数据库
 	toNameDBMap := map[string]string{
-		"order":   "order_db",
-		"goods":   "goods_db",
-		"user":    "user_db",
-		"payment": "payment_db",
+		"order":   filepath.Join(dir, "order_db"),
+		"goods":   filepath.Join(dir, "goods_db"),
+		"user":    filepath.Join(dir, "user_db"),
+		"payment": filepath.Join(dir, "payment_db"),
 	}
 
 	BatchMustRegisterToDB(ctx, group, "source", toNameDBMap)
@@ -390,6 +395,7 @@ func TestBatchMustRegisterToDB_SingleTarget(t *testing.T) {
 func TestFunc_Integration(t *testing.T) {
 	ctx := context.Background()
 	group := New()
+	dir := t.TempDir()
 
 	// 注册多个源数据库
 	sourceConfigs := map[string]DBConfig{
@@ -413,7 +419,7 @@ func TestFunc_Integration(t *testing.T) {
 	}
 
 	// 使用 RegisterToDB 从 master 注册目标
-	isNew, err := RegisterToDB(ctx, group, "master", "order_db", "order_physical")
+	isNew, err := RegisterToDB(ctx, group, "master", "order_db", filepath.Join(dir, "order_physical"))
 	if err != nil {
 		t.Fatalf("RegisterToDB 失败: %v", err)
 	}
@@ -422,15 +428,15 @@ func TestFunc_Integration(t *testing.T) {
 	}
 
 	// 使用 MustRegisterToDB 从 slave 注册目标
-	isNew = MustRegisterToDB(ctx, group, "slave", "user_db", "user_physical")
+	isNew = MustRegisterToDB(ctx, group, "slave", "user_db", filepath.Join(dir, "user_physical"))
 	if !isNew {
 		t.Error("应该是新注册的数据库")
 	}
 
 	// 使用 BatchMustRegisterToDB 从 master 批量注册
 	batchMap := map[string]string{
-		"goods_db":   "goods_physical",
-		"payment_db": "payment_physical",
+		"goods_db":   filepath.Join(dir, "goods_physical"),
+		"payment_db": filepath.Join(dir, "payment_physical"),
 	}
 	BatchMustRegisterToDB(ctx, group, "master", batchMap)
 
@@ -664,6 +670,155 @@ func ExampleMustRegisterToDB() {
 	group.Close(ctx)
 }
 
+// ==================== BatchRegisterToDB 测试 ====================
+
+// failingGroup 注册 failName 时返回错误的 Group，用于测试回滚
+type failingGroup struct {
+	Group
+	failName string
+}
+
+// Register 注册 failName 时返回错误
+func (g failingGroup) Register(ctx context.Context, name string, cfg DBConfig) (bool, error) {
+	if name == g.failName {
+		return false, errors.New("register failed")
+	}
+	return g.Group.Register(ctx, name, cfg)
+}
+
+// TestBatchRegisterToDB 测试并发批量注册，已注册的连接不会被覆盖
+func TestBatchRegisterToDB(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "source", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+	group.Register(ctx, "order", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+
+	toNameDBMap := map[string]string{}
+	for _, name := range []string{"order", "goods", "user", "payment"} {
+		toNameDBMap[name] = filepath.Join(dir, name+".db")
+	}
+	registered, err := BatchRegisterToDB(ctx, group, "source", toNameDBMap, BatchRegisterOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("BatchRegisterToDB() 失败: %v", err)
+	}
+	if got := strings.Join(registered, ","); got != "goods,payment,user" {
+		t.Errorf("新注册的连接 = %s, 期望 goods,payment,user", got)
+	}
+	if cfg := group.MustConfig(ctx, "order"); cfg.DBName != ":memory:" {
+		t.Error("已注册的连接不应被覆盖")
+	}
+	if cfg := group.MustConfig(ctx, "user"); cfg.DBName != toNameDBMap["user"] {
+		t.Errorf("user 的 DBName = %q", cfg.DBName)
+	}
+}
+
+// TestBatchRegisterToDB_Validate 测试任一目标无效时不注册任何连接
+func TestBatchRegisterToDB_Validate(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "source", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+
+	_, err := BatchRegisterToDB(ctx, group, "source", map[string]string{"goods": "goods.db", "order": ""}, BatchRegisterOptions{})
+	if err == nil || !strings.HasPrefix(err.Error(), "order: ") {
+		t.Fatalf("错误应以失败的 toName 开头，实际为: %v", err)
+	}
+	if names := group.List(); len(names) != 1 {
+		t.Errorf("校验失败时不应注册任何连接，实际为 %v", names)
+	}
+
+	if _, err := BatchRegisterToDB(ctx, group, "not_exists", map[string]string{"a": "a.db"}, BatchRegisterOptions{}); err == nil {
+		t.Error("来源不存在时应返回错误")
+	}
+}
+
+// TestBatchRegisterToDB_Rollback 测试打开失败时回滚本次新注册的连接
+func TestBatchRegisterToDB_Rollback(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "source", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+	toNameDBMap := map[string]string{
+		"goods": filepath.Join(dir, "goods.db"),
+		"order": filepath.Join(dir, "not_exists", "order.db"), // 目录不存在，打开失败
+		"user":  filepath.Join(dir, "user.db"),
+	}
+
+	// 未设置 Open 时惰性打开，不检查目标数据库
+	registered, err := BatchRegisterToDB(ctx, group, "source", toNameDBMap, BatchRegisterOptions{})
+	if err != nil || strings.Join(registered, ",") != "goods,order,user" {
+		t.Fatalf("BatchRegisterToDB() = %v, %v", registered, err)
+	}
+	if _, ok := OpenedDB(group, "goods"); ok {
+		t.Error("未设置 Open 时新注册的连接应惰性打开")
+	}
+	for _, name := range registered {
+		group.Unregister(ctx, name)
+	}
+
+	// 不回滚时只注销打开失败的连接，成功注册的连接已打开
+	registered, err = BatchRegisterToDB(ctx, group, "source", toNameDBMap, BatchRegisterOptions{Open: true})
+	if err == nil || !strings.HasPrefix(err.Error(), "order: ") {
+		t.Fatalf("应返回 order 的打开错误，实际为: %v", err)
+	}
+	if got := strings.Join(registered, ","); got != "goods,user" {
+		t.Errorf("新注册的连接 = %s, 期望 goods,user", got)
+	}
+	if _, err := group.Config(ctx, "order"); err == nil {
+		t.Error("打开失败的连接应被注销")
+	}
+	if _, ok := OpenedDB(group, "goods"); !ok {
+		t.Error("设置 Open 时新注册的连接应已打开")
+	}
+	for _, name := range registered {
+		group.Unregister(ctx, name)
+	}
+
+	registered, err = BatchRegisterToDB(ctx, group, "source", toNameDBMap, BatchRegisterOptions{Open: true, Rollback: true, Concurrency: 3})
+	if err == nil || registered != nil {
+		t.Fatalf("回滚时应返回错误且不返回新注册的连接: %v, %v", registered, err)
+	}
+	if names := group.List(); len(names) != 1 || names[0] != "source" {
+		t.Errorf("回滚后只应保留来源连接，实际为 %v", names)
+	}
+	if _, ok := OpenedDB(group, "goods"); ok {
+		t.Error("回滚后应关闭已打开的连接")
+	}
+}
+
+// TestRegisterToDB_ExplicitDSN 测试复制显式设置了 DSN 的连接时只替换 DSN 中的数据库
+func TestRegisterToDB_ExplicitDSN(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "mysql", DBConfig{DriverType: "mysql", DSN: "u:p@tcp(db.internal:3306)/app?parseTime=true"})
+	group.Register(ctx, "pg", DBConfig{DriverType: "postgres", DSN: "postgres://u:p@db.internal:5432/app?sslmode=disable"})
+	group.Register(ctx, "ref", DBConfig{DriverType: "mysql", DSN: "env:MYSQL_DSN"})
+
+	registered, err := BatchRegisterToDB(ctx, group, "mysql", map[string]string{"tenant1": "tenant1"}, BatchRegisterOptions{})
+	if err != nil || len(registered) != 1 {
+		t.Fatalf("BatchRegisterToDB() = %v, %v", registered, err)
+	}
+	if dsn := group.MustConfig(ctx, "tenant1").DSN; dsn != "u:p@tcp(db.internal:3306)/tenant1?parseTime=true" {
+		t.Errorf("MySQL DSN = %q", dsn)
+	}
+	MustRegisterToDB(ctx, group, "pg", "tenant2", "tenant2")
+	if dsn := group.MustConfig(ctx, "tenant2").DSN; dsn != "postgres://u:p@db.internal:5432/tenant2?sslmode=disable" {
+		t.Errorf("PostgreSQL DSN = %q", dsn)
+	}
+
+	// DSN 为密钥引用时无法替换数据库，在注册任何连接之前返回错误
+	if _, err := BatchRegisterToDB(ctx, group, "ref", map[string]string{"tenant3": "tenant3"}, BatchRegisterOptions{}); err == nil {
+		t.Error("DSN 为密钥引用时应返回错误")
+	}
+	if _, err := group.Config(ctx, "tenant3"); err == nil {
+		t.Error("校验失败时不应注册连接")
+	}
+}
+
 // ExampleBatchMustRegisterToDB 展示 BatchMustRegisterToDB 的使用方法
 func ExampleBatchMustRegisterToDB() {
 	ctx := context.Background()
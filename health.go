@@ -0,0 +1,237 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHealthTimeout 健康检查时单个数据库 Ping 的默认超时时间
+const DefaultHealthTimeout = 5 * time.Second
+
+// HealthOptions 健康检查选项
+type HealthOptions struct {
+	// Timeout 单个数据库检查的超时时间，<=0 时使用 DefaultHealthTimeout
+	Timeout time.Duration
+	// ForceOpen 为 true 时通过 Get 打开尚未初始化的连接后再检查；
+	// 为 false 时只检查已打开的连接，未打开的连接标记为未打开且不视为失败
+	ForceOpen bool
+}
+
+// timeout 返回单个数据库检查的超时时间
+func (o HealthOptions) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return DefaultHealthTimeout
+	}
+	return o.Timeout
+}
+
+// HealthStatus 单个数据库的检查结果
+type HealthStatus struct {
+	Group   string        // Group 组名
+	Name    string        // Name 连接名
+	Opened  bool          // Opened 检查时连接是否已打开
+	Latency time.Duration // Latency Ping 耗时，未检查时为 0
+	Err     error         // Err 打开或 Ping 失败时的错误
+	Stats   sql.DBStats   // Stats 检查后的连接池统计，仅 Opened 为 true 时有效
+}
+
+// Healthy 返回连接是否健康，未打开且未强制打开的连接视为健康。
+func (s HealthStatus) Healthy() bool {
+	return s.Err == nil
+}
+
+// HealthReport 健康检查报告
+type HealthReport struct {
+	Statuses []HealthStatus // Statuses 每个已注册连接的检查结果，按组名、连接名排序
+}
+
+// Healthy 返回所有连接是否都健康。
+func (r HealthReport) Healthy() bool {
+	for _, s := range r.Statuses {
+		if !s.Healthy() {
+			return false
+		}
+	}
+	return true
+}
+
+// HealthCheck 并发检查 m 中每个已注册的数据库连接，每个连接使用独立的超时时间。
+//
+// 默认只 Ping 已打开的连接，不会触发惰性初始化；opts.ForceOpen 为 true 时
+// 先通过 Get 并发打开尚未初始化的连接（连接在注册表的锁外打开，互不阻塞）。m 不是由 NewManager 创建且未设置 ForceOpen 时，
+// 无法得知连接是否已打开，返回 ErrStatsUnsupported。
+//
+// 单个连接检查失败不会使 HealthCheck 返回错误，失败信息记录在对应的 HealthStatus 中。
+func HealthCheck(ctx context.Context, m Manager, opts HealthOptions) (HealthReport, error) {
+	if _, ok := m.(*manager); !ok && !opts.ForceOpen {
+		return HealthReport{}, ErrStatsUnsupported
+	}
+
+	groupNames := m.ListGroupNames()
+	sort.Strings(groupNames)
+
+	type target struct {
+		group Group
+		name  string
+	}
+	var (
+		targets  []target
+		statuses []HealthStatus
+	)
+	for _, groupName := range groupNames {
+		g, err := m.Group(groupName)
+		if err != nil {
+			// 组在遍历过程中被关闭
+			continue
+		}
+		names := g.List()
+		sort.Strings(names)
+		for _, name := range names {
+			targets = append(targets, target{group: g, name: name})
+			statuses = append(statuses, HealthStatus{Group: groupName, Name: name})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(s *HealthStatus, g Group, name string) {
+			defer wg.Done()
+			checkHealth(ctx, s, g, name, opts)
+		}(&statuses[i], t.group, t.name)
+	}
+	wg.Wait()
+
+	return HealthReport{Statuses: statuses}, nil
+}
+
+// checkHealth 检查单个连接并把结果写入 s
+func checkHealth(ctx context.Context, s *HealthStatus, g Group, name string, opts HealthOptions) {
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout())
+	defer cancel()
+
+	db, ok := OpenedDB(g, name)
+	if !ok {
+		if !opts.ForceOpen {
+			return
+		}
+		var err error
+		if db, err = g.Get(ctx, name); err != nil {
+			s.Err = err
+			return
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		s.Err = err
+		return
+	}
+	s.Opened = true
+
+	start := time.Now()
+	s.Err = sqlDB.PingContext(ctx)
+	s.Latency = time.Since(start)
+	s.Stats = sqlDB.Stats()
+}
+
+// healthResponse 健康检查接口的响应
+type healthResponse struct {
+	Status    string              `json:"status"`
+	Databases []healthStatusEntry `json:"databases,omitempty"`
+}
+
+// healthStatusEntry 健康检查接口中单个数据库的结果
+type healthStatusEntry struct {
+	Group           string `json:"group"`
+	Name            string `json:"name"`
+	Opened          bool   `json:"opened"`
+	Healthy         bool   `json:"healthy"`
+	Latency         string `json:"latency"`
+	Error           string `json:"error,omitempty"`
+	OpenConnections int    `json:"open_connections"`
+	InUse           int    `json:"in_use"`
+	Idle            int    `json:"idle"`
+}
+
+// NewHealthHandler 基于 HealthCheck 创建用于 Kubernetes 探针的 http.Handler，
+// 按请求路径的最后一段分发：
+//   - /healthz：存活检查，不访问数据库，总是返回 200，避免数据库故障导致 Pod 被反复重启
+//   - /readyz：就绪检查，使用 opts 检查数据库（设置 ForceOpen 时会打开所有连接），
+//     全部健康时返回 200，否则返回 503，响应体为 JSON 格式的检查报告
+//
+// 其他路径返回 404。报告中的错误只包含通用描述，驱动返回的错误（可能包含主机名、用户名）
+// 记录到 slog.Default()，不会写入响应。
+// Handler 可以挂载在任意前缀下，例如 mux.Handle("/db/", h) 后访问 /db/readyz。
+func NewHealthHandler(m Manager, opts HealthOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path.Base(r.URL.Path) {
+		case "healthz":
+			writeHealthResponse(w, http.StatusOK, healthResponse{Status: "ok"})
+			return
+		case "readyz":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		report, err := HealthCheck(r.Context(), m, opts)
+		if err != nil {
+			slog.Default().Error("mgorm: health check failed", slog.Any("error", err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		resp := healthResponse{Status: "ok", Databases: make([]healthStatusEntry, 0, len(report.Statuses))}
+		code := http.StatusOK
+		if !report.Healthy() {
+			resp.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		}
+		for _, s := range report.Statuses {
+			entry := healthStatusEntry{
+				Group:           s.Group,
+				Name:            s.Name,
+				Opened:          s.Opened,
+				Healthy:         s.Healthy(),
+				Latency:         s.Latency.String(),
+				OpenConnections: s.Stats.OpenConnections,
+				InUse:           s.Stats.InUse,
+				Idle:            s.Stats.Idle,
+			}
+			if s.Err != nil {
+				entry.Error = healthErrorText(s)
+				slog.Default().Warn("mgorm: database unhealthy",
+					slog.String("group", s.Group), slog.String("name", s.Name), slog.Any("error", s.Err))
+			}
+			resp.Databases = append(resp.Databases, entry)
+		}
+		writeHealthResponse(w, code, resp)
+	})
+}
+
+// healthErrorText 返回写入响应的通用错误描述
+func healthErrorText(s HealthStatus) string {
+	if errors.Is(s.Err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	if !s.Opened {
+		return "open failed"
+	}
+	return "ping failed"
+}
+
+// writeHealthResponse 以 JSON 写入健康检查接口的响应
+func writeHealthResponse(w http.ResponseWriter, code int, resp healthResponse) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,223 @@
+package mgorm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qq1060656096/bizutil/registry"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newHealthManager 创建包含一个健康连接与一个无法打开的连接的 Manager
+func newHealthManager(t *testing.T) Manager {
+	t.Helper()
+	ctx := context.Background()
+	manager := NewManager()
+	t.Cleanup(func() { manager.Close(ctx) })
+
+	manager.AddGroup("main")
+	main := manager.MustGroup("main")
+	main.Register(ctx, "ok", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+	main.Register(ctx, "bad", DBConfig{
+		DriverType: "sqlite",
+		DBName:     filepath.Join(t.TempDir(), "not_exists", "bad.db"),
+	})
+	return manager
+}
+
+// TestHealthCheck 测试默认只检查已打开的连接
+func TestHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	manager := newHealthManager(t)
+	manager.MustGroup("main").MustGet(ctx, "ok")
+
+	report, err := HealthCheck(ctx, manager, HealthOptions{})
+	if err != nil {
+		t.Fatalf("HealthCheck() 失败: %v", err)
+	}
+	if !report.Healthy() {
+		t.Errorf("未强制打开时报告应为健康: %+v", report)
+	}
+	if len(report.Statuses) != 2 {
+		t.Fatalf("期望 2 条结果，实际 %d 条", len(report.Statuses))
+	}
+
+	bad, ok := report.Statuses[0], report.Statuses[1]
+	if bad.Name != "bad" || bad.Opened {
+		t.Errorf("main/bad 应未打开: %+v", bad)
+	}
+	if ok.Name != "ok" || !ok.Opened || ok.Err != nil {
+		t.Errorf("main/ok 应已打开且健康: %+v", ok)
+	}
+	if ok.Stats.OpenConnections == 0 {
+		t.Error("main/ok 应包含连接池统计")
+	}
+	if _, opened := OpenedDB(manager.MustGroup("main"), "bad"); opened {
+		t.Error("HealthCheck 不应打开 main/bad")
+	}
+}
+
+// TestHealthCheck_ForceOpen 测试强制打开惰性连接并记录失败
+func TestHealthCheck_ForceOpen(t *testing.T) {
+	ctx := context.Background()
+	manager := newHealthManager(t)
+
+	report, err := HealthCheck(ctx, manager, HealthOptions{ForceOpen: true})
+	if err != nil {
+		t.Fatalf("HealthCheck() 失败: %v", err)
+	}
+	if report.Healthy() {
+		t.Error("main/bad 无法打开，报告不应为健康")
+	}
+
+	bad, ok := report.Statuses[0], report.Statuses[1]
+	if bad.Err == nil {
+		t.Error("main/bad 应返回错误")
+	}
+	if !ok.Opened || ok.Err != nil {
+		t.Errorf("main/ok 应被打开且健康: %+v", ok)
+	}
+	if _, opened := OpenedDB(manager.MustGroup("main"), "ok"); !opened {
+		t.Error("ForceOpen 之后 main/ok 应为已打开")
+	}
+}
+
+// TestHealthCheck_PingError 测试已打开连接 Ping 失败
+func TestHealthCheck_PingError(t *testing.T) {
+	ctx := context.Background()
+	manager := newHealthManager(t)
+
+	db := manager.MustGroup("main").MustGet(ctx, "ok")
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	report, err := HealthCheck(ctx, manager, HealthOptions{})
+	if err != nil {
+		t.Fatalf("HealthCheck() 失败: %v", err)
+	}
+	if s := report.Statuses[1]; s.Healthy() {
+		t.Errorf("已关闭的连接 Ping 应失败: %+v", s)
+	}
+}
+
+// TestHealthCheck_Unsupported 测试非 mgorm 创建的 Manager
+func TestHealthCheck_Unsupported(t *testing.T) {
+	ctx := context.Background()
+	m := registry.NewManager[DBConfig, *gorm.DB](opener, closer)
+	defer m.Close(ctx)
+
+	if _, err := HealthCheck(ctx, m, HealthOptions{}); !errors.Is(err, ErrStatsUnsupported) {
+		t.Errorf("错误应为 ErrStatsUnsupported，实际为: %v", err)
+	}
+	if _, err := HealthCheck(ctx, m, HealthOptions{ForceOpen: true}); err != nil {
+		t.Errorf("ForceOpen 时不应返回错误: %v", err)
+	}
+}
+
+// TestHealthCheck_ForceOpenConcurrent 测试 ForceOpen 并发打开连接：两个连接都打开后才能完成初始化
+func TestHealthCheck_ForceOpenConcurrent(t *testing.T) {
+	ctx := context.Background()
+	var opening sync.WaitGroup
+	opening.Add(2)
+	RegisterDriver("barrier_sqlite", DriverSpec{
+		Dialector: func(dsn string) gorm.Dialector {
+			return barrierDialector{Dialector: sqlite.Open(dsn), opening: &opening}
+		},
+		DSN: SQLiteDSN,
+	})
+	t.Cleanup(func() { unregisterDriver("barrier_sqlite") })
+
+	manager := NewManager()
+	defer manager.Close(ctx)
+	manager.AddGroup("main")
+	for _, name := range []string{"a", "b"} {
+		manager.MustGroup("main").Register(ctx, name, DBConfig{DriverType: "barrier_sqlite", DBName: ":memory:"})
+	}
+
+	report, err := HealthCheck(ctx, manager, HealthOptions{Timeout: 5 * time.Second, ForceOpen: true})
+	if err != nil {
+		t.Fatalf("HealthCheck() 失败: %v", err)
+	}
+	if !report.Healthy() {
+		t.Errorf("两个连接应被并发打开: %+v", report)
+	}
+}
+
+// barrierDialector 初始化时等待 opening 中的所有连接都开始初始化，无法并发打开时超时失败
+type barrierDialector struct {
+	gorm.Dialector
+	opening *sync.WaitGroup
+}
+
+// Initialize 实现 gorm.Dialector
+func (d barrierDialector) Initialize(db *gorm.DB) error {
+	d.opening.Done()
+	done := make(chan struct{})
+	go func() {
+		d.opening.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return d.Dialector.Initialize(db)
+	case <-time.After(2 * time.Second):
+		return errors.New("connections are not opened concurrently")
+	}
+}
+
+// TestNewHealthHandler 测试 /healthz 与 /readyz 的状态码与响应内容
+func TestNewHealthHandler(t *testing.T) {
+	manager := newHealthManager(t)
+	handler := NewHealthHandler(manager, HealthOptions{ForceOpen: true})
+
+	tests := []struct {
+		path      string
+		code      int
+		databases int
+	}{
+		// 存活检查不访问数据库，main/bad 无法打开也返回 200
+		{"/healthz", http.StatusOK, 0},
+		{"/db/readyz", http.StatusServiceUnavailable, 2},
+		{"/other", http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.code {
+				t.Fatalf("状态码 = %d, 期望 %d", rec.Code, tt.code)
+			}
+			if tt.code == http.StatusNotFound {
+				return
+			}
+			if strings.Contains(rec.Body.String(), "not_exists") {
+				t.Errorf("响应不应包含驱动的原始错误: %s", rec.Body.String())
+			}
+
+			var resp healthResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if len(resp.Databases) != tt.databases {
+				t.Errorf("响应应包含 %d 个数据库: %+v", tt.databases, resp)
+			}
+			for _, db := range resp.Databases {
+				if db.Name == "bad" && db.Error != "open failed" {
+					t.Errorf("main/bad 的错误应为通用描述，实际为 %q", db.Error)
+				}
+			}
+		})
+	}
+	if _, opened := OpenedDB(manager.MustGroup("main"), "ok"); !opened {
+		t.Error("/readyz 设置 ForceOpen 时应打开连接")
+	}
+}
@@ -0,0 +1,243 @@
+package mgorm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ManagerConfig 多组数据库配置，外层 key 为组名，内层 key 为连接名。
+// 其结构与仓库中的 db.yml 一致（JSON、TOML 格式采用相同的层级）：
+//
+//	public:
+//	  common:
+//	    dsn: "..."
+//	business:
+//	  test_data_1:
+//	    driver_type: "mysql"
+type ManagerConfig map[string]map[string]DBConfig
+
+// ConfigFormat 配置文件格式
+type ConfigFormat string
+
+// 支持的配置文件格式
+const (
+	FormatYAML ConfigFormat = "yaml"
+	FormatJSON ConfigFormat = "json"
+	FormatTOML ConfigFormat = "toml"
+)
+
+// ErrUnknownConfigFormat 当配置文件格式不受支持时返回此错误。
+var ErrUnknownConfigFormat = errors.New("mgorm: unknown config format")
+
+// FormatFromPath 根据文件扩展名推断配置文件格式。
+func FormatFromPath(path string) (ConfigFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		return FormatYAML, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownConfigFormat, path)
+	}
+}
+
+// LoadManagerFromFile 读取配置文件并创建 Manager，文件格式由扩展名决定
+// （.yml/.yaml、.json、.toml）。文件结构参见 ManagerConfig。
+func LoadManagerFromFile(ctx context.Context, path string) (Manager, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("mgorm: open config file: %w", err)
+	}
+	defer f.Close()
+
+	cfgs, err := DecodeManagerConfig(f, format)
+	if err != nil {
+		return nil, err
+	}
+	return LoadManager(ctx, cfgs)
+}
+
+// LoadManagerFromYAML 读取 YAML 配置文件并创建 Manager。
+// 文件格式参见 ManagerConfig。
+func LoadManagerFromYAML(ctx context.Context, path string) (Manager, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("mgorm: open config file: %w", err)
+	}
+	defer f.Close()
+
+	return LoadManagerFromReader(ctx, f)
+}
+
+// LoadManagerFromReader 从 r 中读取 YAML 配置并创建 Manager。
+// 文件格式参见 ManagerConfig。
+func LoadManagerFromReader(ctx context.Context, r io.Reader) (Manager, error) {
+	cfgs, err := DecodeManagerConfig(r, FormatYAML)
+	if err != nil {
+		return nil, err
+	}
+	return LoadManager(ctx, cfgs)
+}
+
+// DecodeManagerConfig 按 format 从 r 中解码 ManagerConfig。
+//
+// 解码是严格的：配置项中出现 DBConfig 未定义的 key（例如把 conn_max_lifetime
+// 误写为 max_lifetime）时返回错误，而不是静默忽略。
+// time.Duration 字段在所有格式中都支持 "30m" 这样的字符串。
+func DecodeManagerConfig(r io.Reader, format ConfigFormat) (ManagerConfig, error) {
+	var (
+		cfgs ManagerConfig
+		err  error
+	)
+	switch format {
+	case FormatYAML:
+		cfgs, err = decodeYAML(r)
+	case FormatJSON:
+		cfgs, err = decodeJSON(r)
+	case FormatTOML:
+		cfgs, err = decodeTOML(r)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownConfigFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mgorm: decode %s config: %w", format, err)
+	}
+	return cfgs, nil
+}
+
+// decodeYAML 严格解码 YAML 配置。
+func decodeYAML(r io.Reader) (ManagerConfig, error) {
+	var cfgs ManagerConfig
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfgs); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return cfgs, nil
+}
+
+// decodeJSON 严格解码 JSON 配置，出错时错误信息包含 group/name 路径。
+func decodeJSON(r io.Reader) (ManagerConfig, error) {
+	var raw map[string]map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	cfgs := make(ManagerConfig, len(raw))
+	for _, groupName := range sortedKeys(raw) {
+		entries := raw[groupName]
+		cfgs[groupName] = make(map[string]DBConfig, len(entries))
+		for _, name := range sortedKeys(entries) {
+			var cfg DBConfig
+			if err := decodeDBConfigJSON(entries[name], &cfg, true); err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", groupName, name, err)
+			}
+			cfgs[groupName][name] = cfg
+		}
+	}
+	return cfgs, nil
+}
+
+// decodeTOML 严格解码 TOML 配置。
+func decodeTOML(r io.Reader) (ManagerConfig, error) {
+	var cfgs ManagerConfig
+	md, err := toml.NewDecoder(r).Decode(&cfgs)
+	if err != nil {
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+		return nil, fmt.Errorf("unknown keys: %s", strings.Join(keys, ", "))
+	}
+	return cfgs, nil
+}
+
+// LoadManager 根据 cfgs 创建 Manager，添加每个组并注册其中的所有连接。
+//
+// 对每个连接：
+//   - 未设置 DSN 时通过 AutoDsn 自动生成
+//   - 未设置 Dialector 时通过 CreateDialector 创建
+//   - Password、DSN 为密钥引用或设置了 DynamicCredentials 时只做校验，DSN 与 Dialector 在打开连接时生成
+//
+// 连接仍然是惰性初始化的，LoadManager 不会打开任何数据库连接。
+// 任一连接配置出错时，返回所有出错项（包含 group/name 路径）合并后的错误，
+// 此时返回的 Manager 为 nil。
+func LoadManager(ctx context.Context, cfgs ManagerConfig) (Manager, error) {
+	manager := NewManager()
+
+	var errs []error
+	for _, groupName := range sortedKeys(cfgs) {
+		manager.AddGroup(groupName)
+		group := manager.MustGroup(groupName)
+
+		entries := cfgs[groupName]
+		for _, name := range sortedKeys(entries) {
+			if err := registerConfig(ctx, group, name, entries[name]); err != nil {
+				errs = append(errs, fmt.Errorf("%s/%s: %w", groupName, name, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		manager.Close(ctx)
+		return nil, errors.Join(errs...)
+	}
+	return manager, nil
+}
+
+// registerConfig 补全 DSN 与 Dialector 后将 cfg 注册到 group。
+func registerConfig(ctx context.Context, group Group, name string, cfg DBConfig) error {
+	cfg, err := prepareConfig(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = group.Register(ctx, name, cfg)
+	return err
+}
+
+// prepareConfig 校验 cfg 并补全 DSN 与 Dialector，返回用于注册的配置。
+func prepareConfig(cfg DBConfig) (DBConfig, error) {
+	if err := cfg.Validate(); err != nil {
+		return DBConfig{}, err
+	}
+
+	if cfg.Dialector == nil && !cfg.resolveAtOpen() {
+		cfg.DSN = cfg.AutoDsn()
+		dialector, err := CreateDialector(cfg.DriverType, cfg.DSN)
+		if err != nil {
+			return DBConfig{}, err
+		}
+		cfg.Dialector = dialector
+	}
+	return cfg, nil
+}
+
+// sortedKeys 返回 m 中按字典序排列的 key，保证注册顺序与错误顺序稳定。
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,328 @@
+package mgorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// TestLoadManagerFromYAML 测试加载仓库自带的 db.yml
+func TestLoadManagerFromYAML(t *testing.T) {
+	ctx := context.Background()
+
+	manager, err := LoadManagerFromYAML(ctx, "db.yml")
+	if err != nil {
+		t.Fatalf("LoadManagerFromYAML() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	groupNames := manager.ListGroupNames()
+	sort.Strings(groupNames)
+	expectedGroups := []string{"business", "public", "sqlite"}
+	if strings.Join(groupNames, ",") != strings.Join(expectedGroups, ",") {
+		t.Fatalf("组列表 = %v, 期望 %v", groupNames, expectedGroups)
+	}
+
+	business := manager.MustGroup("business")
+	names := business.List()
+	sort.Strings(names)
+	if strings.Join(names, ",") != "test_data_1,test_data_2" {
+		t.Errorf("business 组连接 = %v", names)
+	}
+
+	cfg, err := business.Config(ctx, "test_data_1")
+	if err != nil {
+		t.Fatalf("获取配置失败: %v", err)
+	}
+	expectedDSN := "user:password@tcp(127.0.0.1:3306)/test_data_1?charset=utf8mb4&parseTime=True&loc=Local"
+	if cfg.DSN != expectedDSN {
+		t.Errorf("DSN = %q, 期望 %q", cfg.DSN, expectedDSN)
+	}
+	if cfg.Dialector == nil {
+		t.Error("Dialector 应已创建")
+	}
+	if cfg.MaxOpenConns != 50 {
+		t.Errorf("MaxOpenConns = %d, 期望 50", cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime != 30*time.Minute {
+		t.Errorf("ConnMaxLifetime = %v, 期望 30m", cfg.ConnMaxLifetime)
+	}
+
+	memCfg := manager.MustGroup("sqlite").MustConfig(ctx, "memory_db")
+	if memCfg.DSN != ":memory:" {
+		t.Errorf("sqlite DSN = %q, 期望 %q", memCfg.DSN, ":memory:")
+	}
+}
+
+// TestLoadManagerFromYAML_FileNotFound 测试配置文件不存在
+func TestLoadManagerFromYAML_FileNotFound(t *testing.T) {
+	manager, err := LoadManagerFromYAML(context.Background(), "not_exists.yml")
+	if err == nil {
+		t.Fatal("文件不存在时应返回错误")
+	}
+	if manager != nil {
+		t.Error("出错时 Manager 应为 nil")
+	}
+}
+
+// TestLoadManagerFromReader 测试从 Reader 加载并使用连接
+func TestLoadManagerFromReader(t *testing.T) {
+	ctx := context.Background()
+	yml := `
+main:
+  db1:
+    name: "内存数据库"
+    driver_type: "sqlite"
+    db_name: ":memory:"
+    max_open_conns: 1
+`
+	manager, err := LoadManagerFromReader(ctx, strings.NewReader(yml))
+	if err != nil {
+		t.Fatalf("LoadManagerFromReader() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	db, err := manager.MustGroup("main").Get(ctx, "db1")
+	if err != nil {
+		t.Fatalf("Get() 失败: %v", err)
+	}
+	var n int
+	if err := db.Raw("SELECT 1").Scan(&n).Error; err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("SELECT 1 = %d", n)
+	}
+}
+
+// TestLoadManagerFromReader_Errors 测试所有出错项都会带上 group/name 路径返回
+func TestLoadManagerFromReader_Errors(t *testing.T) {
+	yml := `
+a:
+  ok:
+    driver_type: "sqlite"
+    db_name: ":memory:"
+  bad_driver:
+    driver_type: "oracle"
+    dsn: "whatever"
+b:
+  no_dsn:
+    name: "缺少 DSN"
+`
+	manager, err := LoadManagerFromReader(context.Background(), strings.NewReader(yml))
+	if err == nil {
+		t.Fatal("应返回错误")
+	}
+	if manager != nil {
+		t.Error("出错时 Manager 应为 nil")
+	}
+
+	msg := err.Error()
+	for _, path := range []string{"a/bad_driver", "b/no_dsn"} {
+		if !strings.Contains(msg, path) {
+			t.Errorf("错误信息应包含 %q，实际为: %v", path, msg)
+		}
+	}
+	if strings.Contains(msg, "a/ok") {
+		t.Errorf("错误信息不应包含正确的配置项: %v", msg)
+	}
+	if !IsErrNoDSN(err) {
+		t.Errorf("错误应包含 NoDSN 类型，实际为: %v", err)
+	}
+}
+
+// TestLoadManagerFromReader_Empty 测试空配置
+func TestLoadManagerFromReader_Empty(t *testing.T) {
+	manager, err := LoadManagerFromReader(context.Background(), strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("空配置不应返回错误: %v", err)
+	}
+	if len(manager.ListGroupNames()) != 0 {
+		t.Error("空配置不应包含任何组")
+	}
+}
+
+// TestDecodeManagerConfig_Formats 测试 YAML、JSON、TOML 三种格式解码结果一致
+func TestDecodeManagerConfig_Formats(t *testing.T) {
+	inputs := map[ConfigFormat]string{
+		FormatYAML: `
+main:
+  db1:
+    driver_type: "mysql"
+    host: "127.0.0.1"
+    port: 3306
+    user: "root"
+    password: "secret"
+    db_name: "app"
+    charset: "utf8mb4"
+    max_idle_conns: 5
+    max_open_conns: 50
+    conn_max_lifetime: "30m"
+`,
+		FormatJSON: `{
+  "main": {
+    "db1": {
+      "driver_type": "mysql",
+      "host": "127.0.0.1",
+      "port": 3306,
+      "user": "root",
+      "password": "secret",
+      "db_name": "app",
+      "charset": "utf8mb4",
+      "max_idle_conns": 5,
+      "max_open_conns": 50,
+      "conn_max_lifetime": "30m"
+    }
+  }
+}`,
+		FormatTOML: `
+[main.db1]
+driver_type = "mysql"
+host = "127.0.0.1"
+port = 3306
+user = "root"
+password = "secret"
+db_name = "app"
+charset = "utf8mb4"
+max_idle_conns = 5
+max_open_conns = 50
+conn_max_lifetime = "30m"
+`,
+	}
+
+	expected := DBConfig{
+		DriverType:      "mysql",
+		Host:            "127.0.0.1",
+		Port:            3306,
+		User:            "root",
+		Password:        "secret",
+		DBName:          "app",
+		Charset:         "utf8mb4",
+		MaxIdleConns:    5,
+		MaxOpenConns:    50,
+		ConnMaxLifetime: 30 * time.Minute,
+	}
+
+	for format, input := range inputs {
+		t.Run(string(format), func(t *testing.T) {
+			cfgs, err := DecodeManagerConfig(strings.NewReader(input), format)
+			if err != nil {
+				t.Fatalf("DecodeManagerConfig() 失败: %v", err)
+			}
+			if got := cfgs["main"]["db1"]; !reflect.DeepEqual(got, expected) {
+				t.Errorf("解码结果 = %+v, 期望 %+v", got, expected)
+			}
+		})
+	}
+}
+
+// TestDecodeManagerConfig_UnknownKeys 测试未知 key 会在加载时报错
+func TestDecodeManagerConfig_UnknownKeys(t *testing.T) {
+	inputs := map[ConfigFormat]string{
+		FormatYAML: "main:\n  db1:\n    max_lifetime: \"30m\"\n",
+		FormatJSON: `{"main": {"db1": {"max_lifetime": "30m"}}}`,
+		FormatTOML: "[main.db1]\nmax_lifetime = \"30m\"\n",
+	}
+
+	for format, input := range inputs {
+		t.Run(string(format), func(t *testing.T) {
+			_, err := DecodeManagerConfig(strings.NewReader(input), format)
+			if err == nil {
+				t.Fatal("未知 key 应返回错误")
+			}
+			if !strings.Contains(err.Error(), "max_lifetime") {
+				t.Errorf("错误信息应包含未知 key，实际为: %v", err)
+			}
+		})
+	}
+}
+
+// TestDecodeManagerConfig_UnknownFormat 测试不支持的格式
+func TestDecodeManagerConfig_UnknownFormat(t *testing.T) {
+	_, err := DecodeManagerConfig(strings.NewReader(""), ConfigFormat("ini"))
+	if !errors.Is(err, ErrUnknownConfigFormat) {
+		t.Errorf("错误应为 ErrUnknownConfigFormat，实际为: %v", err)
+	}
+}
+
+// TestDecodeManagerConfig_RoundTrip 测试配置编码后再解码保持一致
+func TestDecodeManagerConfig_RoundTrip(t *testing.T) {
+	cfgs := ManagerConfig{
+		"main": {
+			"db1": {
+				Name:            "主库",
+				DriverType:      "postgres",
+				Host:            "db.local",
+				Port:            5432,
+				User:            "postgres",
+				Password:        "pass",
+				DBName:          "app",
+				MaxIdleConns:    2,
+				MaxOpenConns:    20,
+				ConnMaxLifetime: 90 * time.Second,
+			},
+		},
+	}
+
+	encoders := map[ConfigFormat]func(w *bytes.Buffer) error{
+		FormatYAML: func(w *bytes.Buffer) error { return yaml.NewEncoder(w).Encode(cfgs) },
+		FormatJSON: func(w *bytes.Buffer) error { return json.NewEncoder(w).Encode(cfgs) },
+		FormatTOML: func(w *bytes.Buffer) error { return toml.NewEncoder(w).Encode(cfgs) },
+	}
+
+	for format, encode := range encoders {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encode(&buf); err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+			if !strings.Contains(buf.String(), "1m30s") {
+				t.Errorf("conn_max_lifetime 应编码为字符串，实际为:\n%s", buf.String())
+			}
+
+			decoded, err := DecodeManagerConfig(&buf, format)
+			if err != nil {
+				t.Fatalf("解码失败: %v", err)
+			}
+			if !reflect.DeepEqual(decoded["main"]["db1"], cfgs["main"]["db1"]) {
+				t.Errorf("往返结果 = %+v, 期望 %+v", decoded["main"]["db1"], cfgs["main"]["db1"])
+			}
+		})
+	}
+}
+
+// TestLoadManagerFromFile 测试根据扩展名加载配置文件
+func TestLoadManagerFromFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "db.json")
+	data := `{"main": {"db1": {"driver_type": "sqlite", "db_name": ":memory:", "conn_max_lifetime": "10m"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+
+	manager, err := LoadManagerFromFile(ctx, path)
+	if err != nil {
+		t.Fatalf("LoadManagerFromFile() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	cfg := manager.MustGroup("main").MustConfig(ctx, "db1")
+	if cfg.ConnMaxLifetime != 10*time.Minute {
+		t.Errorf("ConnMaxLifetime = %v, 期望 10m", cfg.ConnMaxLifetime)
+	}
+
+	if _, err := LoadManagerFromFile(ctx, "db.ini"); !errors.Is(err, ErrUnknownConfigFormat) {
+		t.Errorf("不支持的扩展名应返回 ErrUnknownConfigFormat，实际为: %v", err)
+	}
+}
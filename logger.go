@@ -0,0 +1,156 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// parseLogLevel 将配置中的日志级别解析为 logger.LogLevel，空字符串返回 0 表示未配置。
+func parseLogLevel(level string) (logger.LogLevel, error) {
+	switch level {
+	case "":
+		return 0, nil
+	case "silent":
+		return logger.Silent, nil
+	case "error":
+		return logger.Error, nil
+	case "warn":
+		return logger.Warn, nil
+	case "info":
+		return logger.Info, nil
+	default:
+		return 0, fmt.Errorf("mgorm: invalid log_level %q, expected silent, error, warn or info", level)
+	}
+}
+
+// BuildLogger 根据 LogLevel、SlowThreshold 等日志字段创建 GORM 日志。
+// 日志输出到 Logger（为 nil 时使用 slog.Default()），并附带以下属性：
+//   - group: 连接所属的组名
+//   - connection: 连接在组内的名称
+//   - name: DBConfig.Name
+//
+// LogLevel 为空或无效时返回 nil，表示不替换 GORM 的日志配置。
+func (c *DBConfig) BuildLogger() logger.Interface {
+	level, err := parseLogLevel(c.LogLevel)
+	if err != nil || level == 0 {
+		return nil
+	}
+
+	base := c.Logger
+	if base == nil {
+		base = slog.Default()
+	}
+	base = base.With(
+		slog.String("group", c.groupName),
+		slog.String("connection", c.connName),
+		slog.String("name", c.Name),
+	)
+
+	return NewSlogLogger(base, logger.Config{
+		LogLevel:                  level,
+		SlowThreshold:             c.SlowThreshold,
+		IgnoreRecordNotFoundError: c.IgnoreRecordNotFoundError,
+		ParameterizedQueries:      c.ParameterizedQueries,
+	})
+}
+
+// SlogLogger 是输出到 log/slog 的 GORM 日志。
+//
+// 每条 SQL 记录包含 sql、duration、rows 属性，出错时还包含 error 属性；
+// 通过 slog.Logger.With 附加的属性（如组名、连接名）会原样输出。
+type SlogLogger struct {
+	logger *slog.Logger
+	config logger.Config
+}
+
+// NewSlogLogger 创建输出到 l 的 GORM 日志，config 中的 Colorful 被忽略。
+func NewSlogLogger(l *slog.Logger, config logger.Config) *SlogLogger {
+	return &SlogLogger{logger: l, config: config}
+}
+
+// LogMode 返回使用 level 的日志副本。
+func (l *SlogLogger) LogMode(level logger.LogLevel) logger.Interface {
+	newLogger := *l
+	newLogger.config.LogLevel = level
+	return &newLogger
+}
+
+// Info 以 info 级别记录消息。
+func (l *SlogLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= logger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn 以 warn 级别记录消息。
+func (l *SlogLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= logger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error 以 error 级别记录消息。
+func (l *SlogLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= logger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace 记录一次 SQL 执行：
+//   - 出错（且不是被忽略的 ErrRecordNotFound）时以 error 级别记录
+//   - 耗时超过 SlowThreshold 时以 warn 级别记录
+//   - 日志级别为 info 时记录所有 SQL
+func (l *SlogLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.config.LogLevel <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	var (
+		level slog.Level
+		msg   string
+	)
+	switch {
+	case err != nil && l.config.LogLevel >= logger.Error &&
+		(!l.config.IgnoreRecordNotFoundError || !errors.Is(err, gorm.ErrRecordNotFound)):
+		level, msg = slog.LevelError, "sql error"
+	case l.config.SlowThreshold != 0 && elapsed > l.config.SlowThreshold && l.config.LogLevel >= logger.Warn:
+		level, msg = slog.LevelWarn, "slow sql"
+	case l.config.LogLevel >= logger.Info:
+		level, msg = slog.LevelInfo, "sql"
+	default:
+		return
+	}
+
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Duration("duration", elapsed),
+		slog.Int64("rows", rows),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if level == slog.LevelWarn {
+		attrs = append(attrs, slog.Duration("slow_threshold", l.config.SlowThreshold))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter，开启 ParameterizedQueries 时日志中不包含参数值。
+func (l *SlogLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.config.ParameterizedQueries {
+		return sql, nil
+	}
+	return sql, params
+}
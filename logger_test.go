@@ -0,0 +1,176 @@
+package mgorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestSlog 返回写入 buf 的 JSON slog.Logger
+func newTestSlog(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// decodeLogLines 将 JSON 日志按行解码
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("解析日志失败: %v, line=%s", err, line)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+// TestSlogLogger_Attributes 测试日志包含组名、连接名、配置名称、耗时与影响行数
+func TestSlogLogger_Attributes(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+
+	manager := NewManager()
+	manager.AddGroup("business")
+	group := manager.MustGroup("business")
+	_, err := group.Register(ctx, "order", DBConfig{
+		Name:       "订单库",
+		DriverType: "sqlite",
+		DBName:     ":memory:",
+		LogLevel:   "info",
+		Logger:     newTestSlog(&buf),
+	})
+	if err != nil {
+		t.Fatalf("Register() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	db := group.MustGet(ctx, "order")
+	buf.Reset()
+	if err := db.Exec("CREATE TABLE t (id INTEGER)").Error; err != nil {
+		t.Fatalf("Exec() 失败: %v", err)
+	}
+
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 1 {
+		t.Fatalf("期望 1 条日志，实际 %d 条: %s", len(lines), buf.String())
+	}
+	line := lines[0]
+	expected := map[string]any{
+		"level":      "INFO",
+		"group":      "business",
+		"connection": "order",
+		"name":       "订单库",
+		"sql":        "CREATE TABLE t (id INTEGER)",
+	}
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("日志属性 %s = %v, 期望 %v", key, line[key], value)
+		}
+	}
+	if _, ok := line["duration"]; !ok {
+		t.Error("日志应包含 duration")
+	}
+	if _, ok := line["rows"]; !ok {
+		t.Error("日志应包含 rows")
+	}
+}
+
+// TestSlogLogger_Levels 测试错误、慢查询与日志级别过滤
+func TestSlogLogger_Levels(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+
+	group := New()
+	defer group.Close(ctx)
+	_, err := group.Register(ctx, "db", DBConfig{
+		DriverType:    "sqlite",
+		DBName:        ":memory:",
+		LogLevel:      "warn",
+		SlowThreshold: time.Nanosecond,
+		Logger:        newTestSlog(&buf),
+	})
+	if err != nil {
+		t.Fatalf("Register() 失败: %v", err)
+	}
+	db := group.MustGet(ctx, "db")
+
+	// 慢查询以 warn 级别记录
+	buf.Reset()
+	db.Exec("SELECT 1")
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 1 || lines[0]["level"] != "WARN" || lines[0]["msg"] != "slow sql" {
+		t.Fatalf("慢查询日志不符合预期: %s", buf.String())
+	}
+	if lines[0]["connection"] != "db" {
+		t.Errorf("connection = %v, 期望 db", lines[0]["connection"])
+	}
+
+	// 出错时以 error 级别记录
+	buf.Reset()
+	db.Exec("SELECT * FROM not_exists")
+	lines = decodeLogLines(t, &buf)
+	if len(lines) != 1 || lines[0]["level"] != "ERROR" || lines[0]["error"] == nil {
+		t.Fatalf("错误日志不符合预期: %s", buf.String())
+	}
+}
+
+// TestSlogLogger_IgnoreRecordNotFoundAndParameterized 测试忽略记录不存在错误与参数化查询
+func TestSlogLogger_IgnoreRecordNotFoundAndParameterized(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+
+	group := New()
+	defer group.Close(ctx)
+	_, err := group.Register(ctx, "db", DBConfig{
+		DriverType:                "sqlite",
+		DBName:                    ":memory:",
+		LogLevel:                  "error",
+		IgnoreRecordNotFoundError: true,
+		Logger:                    newTestSlog(&buf),
+	})
+	if err != nil {
+		t.Fatalf("Register() 失败: %v", err)
+	}
+	db := group.MustGet(ctx, "db")
+	if err := db.AutoMigrate(&TestModel{}); err != nil {
+		t.Fatalf("AutoMigrate() 失败: %v", err)
+	}
+
+	buf.Reset()
+	var m TestModel
+	if err := db.First(&m, 1).Error; err != gorm.ErrRecordNotFound {
+		t.Fatalf("First() 错误 = %v, 期望 ErrRecordNotFound", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("ErrRecordNotFound 不应被记录: %s", buf.String())
+	}
+
+	// 参数化查询：日志中不包含参数值
+	l := NewSlogLogger(newTestSlog(&buf), logger.Config{LogLevel: logger.Info, ParameterizedQueries: true})
+	sql, params := l.ParamsFilter(ctx, "SELECT ?", "secret")
+	if sql != "SELECT ?" || params != nil {
+		t.Errorf("ParamsFilter() = %q, %v", sql, params)
+	}
+}
+
+// TestDBConfig_InvalidLogLevel 测试无效的日志级别
+func TestDBConfig_InvalidLogLevel(t *testing.T) {
+	config := DBConfig{DriverType: "sqlite", DBName: ":memory:", LogLevel: "debug"}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "log_level") {
+		t.Errorf("无效的日志级别应返回错误，实际为: %v", err)
+	}
+	if config.BuildLogger() != nil {
+		t.Error("无效的日志级别不应创建日志")
+	}
+}
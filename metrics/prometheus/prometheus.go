@@ -0,0 +1,110 @@
+// Package prometheus 提供 mgorm 连接池的 Prometheus 采集器。
+//
+// Collector 在每次采集时遍历 Manager 的所有组与连接，读取已打开连接池的 sql.DBStats，
+// 不会打开尚未初始化的连接。所有指标都带有 group、name 两个标签：
+//
+//	manager := mgorm.NewManager()
+//	prometheus.MustRegister(mgormprom.NewCollector(manager, ""))
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/qq1060656096/mgorm"
+)
+
+// DefaultNamespace 是 NewCollector 未指定命名空间时使用的指标前缀
+const DefaultNamespace = "mgorm"
+
+// labels 是所有指标共用的标签
+var labels = []string{"group", "name"}
+
+// Collector 采集 Manager 中所有已打开连接池的统计信息，实现 prometheus.Collector。
+type Collector struct {
+	manager mgorm.Manager
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxIdleTimeClosed *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+// NewCollector 创建 manager 的连接池采集器，namespace 为空时使用 DefaultNamespace。
+func NewCollector(manager mgorm.Manager, namespace string) *Collector {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db", name), help, labels, nil)
+	}
+
+	return &Collector{
+		manager:           manager,
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:              desc("open_connections", "The number of established connections both in use and idle."),
+		inUse:             desc("in_use_connections", "The number of connections currently in use."),
+		idle:              desc("idle_connections", "The number of idle connections."),
+		waitCount:         desc("wait_count_total", "The total number of connections waited for."),
+		waitDuration:      desc("wait_duration_seconds_total", "The total time blocked waiting for a new connection."),
+		maxIdleClosed:     desc("max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns."),
+		maxIdleTimeClosed: desc("max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime."),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime."),
+	}
+}
+
+// Describe 实现 prometheus.Collector。
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxIdleTimeClosed
+	ch <- c.maxLifetimeClosed
+}
+
+// Collect 实现 prometheus.Collector，只采集已打开的连接池。
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, groupName := range c.manager.ListGroupNames() {
+		group, err := c.manager.Group(groupName)
+		if err != nil {
+			// 组在遍历过程中被关闭
+			continue
+		}
+
+		for _, name := range group.List() {
+			db, ok := mgorm.OpenedDB(group, name)
+			if !ok {
+				continue
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				continue
+			}
+
+			s := sqlDB.Stats()
+			gauge := func(desc *prometheus.Desc, v float64) {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, groupName, name)
+			}
+			counter := func(desc *prometheus.Desc, v float64) {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, groupName, name)
+			}
+
+			gauge(c.maxOpen, float64(s.MaxOpenConnections))
+			gauge(c.open, float64(s.OpenConnections))
+			gauge(c.inUse, float64(s.InUse))
+			gauge(c.idle, float64(s.Idle))
+			counter(c.waitCount, float64(s.WaitCount))
+			counter(c.waitDuration, s.WaitDuration.Seconds())
+			counter(c.maxIdleClosed, float64(s.MaxIdleClosed))
+			counter(c.maxIdleTimeClosed, float64(s.MaxIdleTimeClosed))
+			counter(c.maxLifetimeClosed, float64(s.MaxLifetimeClosed))
+		}
+	}
+}
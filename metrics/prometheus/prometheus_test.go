@@ -0,0 +1,62 @@
+package prometheus
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/qq1060656096/mgorm"
+	_ "github.com/qq1060656096/mgorm/driver/sqlite"
+)
+
+// TestCollector 测试通过本地 Registry 采集已打开连接池的指标
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+	manager := mgorm.NewManager()
+	defer manager.Close(ctx)
+
+	manager.AddGroup("business")
+	group := manager.MustGroup("business")
+	group.Register(ctx, "order", mgorm.DBConfig{DriverType: "sqlite", DBName: ":memory:", MaxOpenConns: 4})
+	group.Register(ctx, "goods", mgorm.DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+
+	// 只打开 order
+	group.MustGet(ctx, "order")
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(NewCollector(manager, ""))
+
+	expected := `
+# HELP mgorm_db_max_open_connections Maximum number of open connections to the database.
+# TYPE mgorm_db_max_open_connections gauge
+mgorm_db_max_open_connections{group="business",name="order"} 4
+# HELP mgorm_db_open_connections The number of established connections both in use and idle.
+# TYPE mgorm_db_open_connections gauge
+mgorm_db_open_connections{group="business",name="order"} 1
+# HELP mgorm_db_in_use_connections The number of connections currently in use.
+# TYPE mgorm_db_in_use_connections gauge
+mgorm_db_in_use_connections{group="business",name="order"} 0
+# HELP mgorm_db_wait_count_total The total number of connections waited for.
+# TYPE mgorm_db_wait_count_total counter
+mgorm_db_wait_count_total{group="business",name="order"} 0
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"mgorm_db_max_open_connections",
+		"mgorm_db_open_connections",
+		"mgorm_db_in_use_connections",
+		"mgorm_db_wait_count_total",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未打开的连接不会被采集，也不会被打开
+	if n := testutil.CollectAndCount(NewCollector(manager, "app"), "app_db_idle_connections"); n != 1 {
+		t.Errorf("idle_connections 指标数 = %d, 期望 1", n)
+	}
+	if _, ok := mgorm.OpenedDB(group, "goods"); ok {
+		t.Error("采集不应打开 goods")
+	}
+}
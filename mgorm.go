@@ -1,10 +1,18 @@
 // Package mgorm 提供基于 GORM 的数据库连接管理功能。
 // 该包封装了数据库连接的创建、配置和生命周期管理，
 // 支持连接池配置、多数据库实例管理等功能。
+//
+// 根包不依赖任何数据库驱动，通过 DriverType 使用的驱动需要导入 driver 目录下
+// 对应的子包（如 github.com/qq1060656096/mgorm/driver/mysql）完成注册。
 package mgorm
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/qq1060656096/bizutil/registry"
 	"gorm.io/gorm"
@@ -12,10 +20,14 @@ import (
 
 // opener 根据配置创建并初始化数据库连接。
 // 该函数会执行以下操作：
-//   - 验证数据库配置的有效性
-//   - 使用配置的 Dialector 打开数据库连接
-//   - 设置连接池参数（最大空闲连接数、最大打开连接数、连接最大存活时间）
-//   - 通过 Ping 验证数据库连接是否可用
+//   - 解析 Password、DSN 中的密钥引用（如 env:DB_PASS），参见 SecretProvider；
+//     设置了 DynamicCredentials 时每个新的物理连接都重新解析
+//   - 验证数据库配置的有效性并解析 Dialector（显式 Dialector → DSN+DriverType → AutoDsn 字段）
+//   - 使用解析出的 Dialector 与 BuildGormConfig 生成的 gorm.Config 打开数据库连接
+//   - 配置了 Replicas 时注册读写分离插件并打开从库
+//   - 设置连接池参数（最大空闲连接数、最大打开连接数、连接最大存活时间、连接最大空闲时间）
+//   - 通过 Ping 验证主库与从库连接是否可用，设置了 ReplicaProbeInterval 时启动从库探测
+//   - 打开或 Ping 失败且错误可重试时，按 RetryMaxAttempts 等重试参数退避后重试
 //
 // 参数：
 //   - ctx: 上下文，用于控制连接超时
@@ -23,42 +35,51 @@ import (
 //
 // 返回：
 //   - *gorm.DB: 成功时返回 GORM 数据库实例
-//   - error: 配置验证失败、连接失败或 Ping 失败时返回错误
+//   - error: 配置验证失败、连接失败或 Ping 失败时返回错误，重试时错误中包含尝试次数
+//
+// 通过 RegisterToSchema 以 SharedPool 模式注册的连接需要获取来源连接，由 group.open 打开。
 func opener(ctx context.Context, cfg DBConfig) (*gorm.DB, error) {
-	if err := cfg.Validate(); err != nil {
-		return nil, err
+	if cfg.sharedFrom != "" {
+		return nil, fmt.Errorf("mgorm: %s shares the pool of %s and must be opened through its Group", cfg.connName, cfg.sharedFrom)
 	}
 
-	db, err := gorm.Open(cfg.Dialector, &gorm.Config{})
+	dialector, err := cfg.openDialector(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	sqlDB, err := db.DB()
-	if err != nil {
-		return nil, err
-	}
+	return cfg.retryOpen(ctx, cfg.retryClassifier(dialector), func(ctx context.Context) (*gorm.DB, error) {
+		db, err := gorm.Open(dialector, cfg.BuildGormConfig())
+		if err != nil {
+			return nil, err
+		}
+		if cd, ok := dialector.(credentialDialector); ok {
+			// 恢复原始方言，保留其实现的 SavePoint、Translate 等可选接口
+			db.Dialector = cd.Dialector
+		}
 
-	if cfg.MaxIdleConns > 0 {
-		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	}
-	if cfg.MaxOpenConns > 0 {
-		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	}
-	if cfg.ConnMaxLifetime > 0 {
-		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
-	}
+		if err := cfg.useReplicas(ctx, db); err != nil {
+			_ = closeSQLDBs(db)
+			return nil, err
+		}
 
-	if err := sqlDB.PingContext(ctx); err != nil {
-		_ = sqlDB.Close()
-		return nil, err
-	}
+		// 主库与从库使用相同的连接池参数，并且都必须可用
+		err = eachSQLDB(db, func(sqlDB *sql.DB) error {
+			cfg.applyPool(sqlDB)
+			return sqlDB.PingContext(ctx)
+		})
+		if err != nil {
+			_ = closeSQLDBs(db)
+			return nil, err
+		}
 
-	return db, nil
+		startReplicaProbe(db)
+		return db, nil
+	})
 }
 
 // closer 关闭数据库连接。
-// 该函数会安全地关闭 GORM 数据库实例底层的 SQL 连接。
+// 该函数会安全地关闭 GORM 数据库实例底层的 SQL 连接（包括读写分离的从库连接）。
 // 如果传入的 db 为 nil，则直接返回 nil 不执行任何操作。
 //
 // 参数：
@@ -71,11 +92,7 @@ func closer(ctx context.Context, db *gorm.DB) error {
 	if db == nil {
 		return nil
 	}
-	sqlDB, err := db.DB()
-	if err != nil {
-		return err
-	}
-	return sqlDB.Close()
+	return closeSQLDBs(db)
 }
 
 // Group 是单一组管理（key => redis client）
@@ -88,13 +105,21 @@ type Manager = registry.Manager[DBConfig, *gorm.DB]
 // 返回的 Manager 实例用于管理单个数据库连接的生命周期，
 // 包括连接的创建、获取和关闭。
 //
+// 通过返回的 Manager 获取的 Group 在注册连接时会记录组名与连接名，
+// 用于日志等场景标识查询所在的数据库。
+//
 // 返回：
 //   - registry.Manager[DBConfig, *gorm.DB]: 数据库连接管理器实例
 func NewManager() Manager {
-	return registry.NewManager[DBConfig, *gorm.DB](
-		opener,
-		closer,
-	)
+	p := newPools()
+	return &manager{
+		Manager: registry.NewManager[DBConfig, *gorm.DB](
+			p.opener,
+			p.closer,
+		),
+		pools:   p,
+		removed: make(map[string]struct{}),
+	}
 }
 
 // New 创建一个新的数据库连接分组管理器。
@@ -104,8 +129,182 @@ func NewManager() Manager {
 // 返回：
 //   - registry.Group[DBConfig, *gorm.DB]: 数据库连接分组管理器实例
 func New() Group {
-	return registry.New[DBConfig, *gorm.DB](
-		opener,
-		closer,
-	)
+	p := newPools()
+	return &group{
+		Group: registry.New[DBConfig, *gorm.DB](
+			p.opener,
+			p.closer,
+		),
+		pools: p,
+	}
+}
+
+// manager 包装 registry.Manager，使其返回的 Group 为 *group。
+type manager struct {
+	Manager
+	pools *pools // pools 记录所有组中已打开的连接
+
+	mu      sync.RWMutex
+	removed map[string]struct{} // removed 通过 removeGroup 删除的组，registry 不支持删除组，由此隐藏
+}
+
+// AddGroup 添加资源组，返回组是否已经存在；已被 removeGroup 删除的组重新添加。
+func (m *manager) AddGroup(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.removed[name]; ok {
+		delete(m.removed, name)
+		m.Manager.AddGroup(name)
+		return false
+	}
+	return m.Manager.AddGroup(name)
+}
+
+// ListGroupNames 返回所有资源组的名称，不包括已删除的组。
+func (m *manager) ListGroupNames() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var names []string
+	for _, name := range m.Manager.ListGroupNames() {
+		if _, ok := m.removed[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Close 关闭所有组中已打开的连接，之后 Manager 为空。
+func (m *manager) Close(ctx context.Context) []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.removed)
+	return m.Manager.Close(ctx)
+}
+
+// removeGroup 删除不再包含任何连接的组，之后 Group 返回 registry.ErrGroupNotFound，返回是否删除。
+func (m *manager) removeGroup(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, err := m.Manager.Group(name)
+	if err != nil || len(g.List()) > 0 {
+		return false
+	}
+	m.removed[name] = struct{}{}
+	return true
+}
+
+// Group 获取指定名称的资源组。
+func (m *manager) Group(name string) (Group, error) {
+	m.mu.RLock()
+	_, removed := m.removed[name]
+	m.mu.RUnlock()
+	if removed {
+		return nil, registry.NewErrGroupNotFound(name)
+	}
+	g, err := m.Manager.Group(name)
+	if err != nil {
+		return nil, err
+	}
+	return &group{Group: g, name: name, pools: m.pools, manager: m}, nil
+}
+
+// MustGroup 获取指定名称的资源组，不存在时 panic。
+func (m *manager) MustGroup(name string) Group {
+	g, err := m.Group(name)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// group 包装 registry.Group，注册时把组名与连接名记录到 DBConfig 中，
+// 使 opener 创建连接时能够知道连接在 Manager 中的位置；
+// 打开连接时记录已打开的连接，用于在不触发惰性初始化的情况下查询连接状态。
+type group struct {
+	Group
+	name    string   // name 是组名，New 创建的单组为空
+	pools   *pools   // pools 记录已打开的连接
+	manager *manager // manager 是组所在的 Manager，New 创建的单组为 nil
+}
+
+// regIDs 生成 DBConfig.regID
+var regIDs atomic.Uint64
+
+// Register 记录组名、连接名与注册序号后注册连接配置。
+func (g *group) Register(ctx context.Context, name string, cfg DBConfig) (bool, error) {
+	cfg.groupName = g.name
+	cfg.connName = name
+	cfg.regID = regIDs.Add(1)
+	return g.Group.Register(ctx, name, cfg)
+}
+
+// Get 获取数据库连接（首次调用时创建），创建的连接由 pools.opener 记录为已打开。
+//
+// registry 在其全局写锁内调用 opener，因此首次打开时先在锁外通过 pools.preopen 建立连接（包括 Ping 与重试的退避等待），
+// 再交给 opener 完成注册：一个连接打开缓慢或不断重试时，不会阻塞其他连接的 Get、Register 等操作。
+func (g *group) Get(ctx context.Context, name string) (*gorm.DB, error) {
+	var regID uint64
+	for {
+		g.pools.swapMu.RLock()
+		db, err := g.Group.Get(ctx, name)
+		g.pools.swapMu.RUnlock()
+		if !errors.Is(err, errNotPreopened) {
+			if regID != 0 {
+				g.pools.discardPreopened(regID)
+			}
+			return db, err
+		}
+
+		cfg, err := g.Group.Config(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if regID != 0 && regID != cfg.regID {
+			// 打开期间连接被重新注册
+			g.pools.discardPreopened(regID)
+		}
+		regID = cfg.regID
+		if err := g.pools.preopen(ctx, cfg.regID, func(ctx context.Context) (*gorm.DB, error) { return g.open(ctx, cfg) }); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// open 在 registry 的锁外打开 cfg 的连接；SharedPool 模式的连接在来源连接的连接池上打开，
+// 每次使用时通过 Get 获取来源连接当前的连接池，参见 sharedConnPool
+func (g *group) open(ctx context.Context, cfg DBConfig) (*gorm.DB, error) {
+	if cfg.sharedFrom == "" {
+		return opener(ctx, cfg)
+	}
+	src, err := g.Get(ctx, cfg.sharedFrom)
+	if err != nil {
+		return nil, fmt.Errorf("mgorm: open shared pool source %s: %w", cfg.sharedFrom, err)
+	}
+	return cfg.openShared(src, &sharedConnPool{group: g, name: cfg.sharedFrom})
+}
+
+// Ping 使用注册的配置打开一个临时连接验证可用性后立即关闭，不影响已打开连接的记录。
+// SharedPool 模式的连接检查其来源连接。
+func (g *group) Ping(ctx context.Context, name string) error {
+	cfg, err := g.Group.Config(ctx, name)
+	if err != nil {
+		return err
+	}
+	if cfg.sharedFrom != "" {
+		return g.Ping(ctx, cfg.sharedFrom)
+	}
+	db, err := opener(ctx, cfg)
+	if err != nil {
+		return registry.NewErrPingResourceFailed(g.name, name, err)
+	}
+	return closer(ctx, db)
+}
+
+// MustGet 获取数据库连接，失败时 panic。
+func (g *group) MustGet(ctx context.Context, name string) *gorm.DB {
+	db, err := g.Get(ctx, name)
+	if err != nil {
+		panic(err)
+	}
+	return db
 }
@@ -545,6 +545,39 @@ func TestOpener_WithContext(t *testing.T) {
 	}
 }
 
+// TestOpener_AutoDialector 测试 opener 在未设置 Dialector 时根据 DriverType 与 DSN 字段自动创建
+func TestOpener_AutoDialector(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		config DBConfig
+	}{
+		{
+			name:   "DSN + DriverType",
+			config: DBConfig{DriverType: "sqlite", DSN: ":memory:"},
+		},
+		{
+			name:   "根据字段自动生成 DSN",
+			config: DBConfig{DriverType: "sqlite", DBName: ":memory:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := opener(ctx, tt.config)
+			if err != nil {
+				t.Fatalf("opener() 失败: %v", err)
+			}
+			defer closer(ctx, db)
+
+			if db.Dialector.Name() != "sqlite" {
+				t.Errorf("Dialector.Name() = %q, 期望 sqlite", db.Dialector.Name())
+			}
+		})
+	}
+}
+
 // TestOpener_ValidationError 测试 opener 函数处理无效配置
 func TestOpener_ValidationError(t *testing.T) {
 	ctx := context.Background()
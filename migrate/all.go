@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/qq1060656096/mgorm"
+)
+
+// MigrateAllOptions MigrateAll 的选项
+type MigrateAllOptions struct {
+	// Migrator 执行迁移的 Migrator（必需）
+	Migrator *Migrator
+	// Names 需要迁移的连接名，为空时迁移 Group 中注册的所有连接
+	Names []string
+	// Concurrency 同时迁移的数据库数量上限，0 或 1 时按连接名依次迁移
+	Concurrency int
+}
+
+// Result 单个数据库的迁移结果
+type Result struct {
+	Name     string        // Name 连接名
+	Applied  []int64       // Applied 本次执行的版本号
+	Duration time.Duration // Duration 迁移耗时
+	Err      error         // Err 获取连接或迁移失败时的错误
+}
+
+// Report 所有数据库的迁移结果
+type Report struct {
+	Results []Result // Results 按连接名排序
+}
+
+// Failed 返回迁移失败的结果
+func (r Report) Failed() []Result {
+	var failed []Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err 返回合并后的错误，每个错误以失败的连接名开头；全部成功时返回 nil
+func (r Report) Err() error {
+	var errs []error
+	for _, result := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", result.Name, result.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// MigrateAll 对 group 中的每个连接执行尚未执行的迁移，返回每个数据库的结果与合并后的错误。
+//
+// 单个数据库失败不影响其他数据库。连接按名称排序，设置了 Concurrency 时并发迁移；
+// 尚未打开的连接会被打开。迁移多组时，可以对 Manager.ListGroupNames 返回的每个组分别调用。
+func MigrateAll(ctx context.Context, group mgorm.Group, opts MigrateAllOptions) (Report, error) {
+	if opts.Migrator == nil {
+		return Report{}, errors.New("migrate: MigrateAllOptions.Migrator is required")
+	}
+
+	names := slices.Clone(opts.Names)
+	if len(names) == 0 {
+		names = group.List()
+	}
+	slices.Sort(names)
+	results := make([]Result, len(names))
+	for i, name := range names {
+		results[i].Name = name
+	}
+
+	migrate := func(result *Result) {
+		start := time.Now()
+		db, err := group.Get(ctx, result.Name)
+		if err == nil {
+			result.Applied, err = opts.Migrator.Up(ctx, db)
+		}
+		result.Err = err
+		result.Duration = time.Since(start)
+	}
+
+	if opts.Concurrency <= 1 {
+		for i := range results {
+			migrate(&results[i])
+		}
+	} else {
+		sem := make(chan struct{}, opts.Concurrency)
+		var wg sync.WaitGroup
+		for i := range results {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(result *Result) {
+				defer func() { <-sem; wg.Done() }()
+				migrate(result)
+			}(&results[i])
+		}
+		wg.Wait()
+	}
+
+	report := Report{Results: results}
+	return report, report.Err()
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qq1060656096/mgorm"
+	"gorm.io/gorm"
+)
+
+// TestMigrateAll 测试并发迁移组内所有数据库，单个数据库失败不影响其他数据库
+func TestMigrateAll(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	group := mgorm.New()
+	defer group.Close(ctx)
+	for _, name := range []string{"tenant_c", "tenant_a", "tenant_b"} {
+		group.Register(ctx, name, mgorm.DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, name+".db")})
+	}
+	// 打开时失败的连接
+	group.Register(ctx, "broken", mgorm.DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, "missing", "x.db")})
+
+	m, _ := New(Config{Migrations: []Migration{createTable(1, "users"), createTable(2, "orders")}})
+	report, err := MigrateAll(ctx, group, MigrateAllOptions{Migrator: m, Concurrency: 2})
+	if err == nil || !strings.HasPrefix(err.Error(), "broken: ") {
+		t.Fatalf("错误应以失败的连接名开头，实际为: %v", err)
+	}
+
+	var names []string
+	for _, result := range report.Results {
+		names = append(names, result.Name)
+		if result.Name != "broken" && (result.Err != nil || fmt.Sprint(result.Applied) != "[1 2]") {
+			t.Errorf("%s 的结果 = %v, %v", result.Name, result.Applied, result.Err)
+		}
+	}
+	if got := strings.Join(names, ","); got != "broken,tenant_a,tenant_b,tenant_c" {
+		t.Errorf("结果应按连接名排序，实际为 %s", got)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Name != "broken" {
+		t.Errorf("Failed() = %v", failed)
+	}
+
+	// 再次迁移指定连接时没有待执行的版本
+	report, err = MigrateAll(ctx, group, MigrateAllOptions{Migrator: m, Names: []string{"tenant_a"}})
+	if err != nil || len(report.Results) != 1 || len(report.Results[0].Applied) != 0 {
+		t.Errorf("重复迁移 = %+v, %v", report, err)
+	}
+
+	if _, err := MigrateAll(ctx, group, MigrateAllOptions{}); err == nil {
+		t.Error("缺少 Migrator 时应返回错误")
+	}
+}
+
+// sharedUser 通过模型创建的表，表名随连接的表前缀变化
+type sharedUser struct {
+	ID int
+}
+
+// TestMigrateAll_SharedPool 测试共用连接池的租户各自使用带表前缀的迁移记录表
+func TestMigrateAll_SharedPool(t *testing.T) {
+	ctx := context.Background()
+	group := mgorm.New()
+	defer group.Close(ctx)
+	group.Register(ctx, "main", mgorm.DBConfig{DriverType: "sqlite", DBName: filepath.Join(t.TempDir(), "main.db")})
+	for name, prefix := range map[string]string{"tenant_a": "a_", "tenant_b": "b_"} {
+		if _, err := mgorm.RegisterToSchema(ctx, group, "main", name, mgorm.SchemaConfig{TablePrefix: prefix, SharedPool: true}); err != nil {
+			t.Fatalf("RegisterToSchema(%s) = %v", name, err)
+		}
+	}
+
+	m, _ := New(Config{Migrations: []Migration{{
+		Version: 1,
+		Name:    "create_users",
+		Up: func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&sharedUser{})
+		},
+	}}})
+	report, err := MigrateAll(ctx, group, MigrateAllOptions{Migrator: m, Names: []string{"tenant_a", "tenant_b"}})
+	if err != nil {
+		t.Fatalf("MigrateAll() = %v", err)
+	}
+	for _, result := range report.Results {
+		if fmt.Sprint(result.Applied) != "[1]" {
+			t.Errorf("%s 的结果 = %v，两个租户都应执行迁移", result.Name, result.Applied)
+		}
+	}
+
+	db, _ := group.Get(ctx, "main")
+	for _, table := range []string{"a_schema_migrations", "a_schema_migrations_lock", "a_shared_users", "b_schema_migrations", "b_schema_migrations_lock", "b_shared_users"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("缺少表 %s", table)
+		}
+	}
+	if db.Migrator().HasTable(DefaultTable) {
+		t.Error("租户不应使用没有前缀的迁移记录表")
+	}
+}
@@ -0,0 +1,127 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// sqlFilePattern SQL 迁移文件名：<版本号>_<名称>.up.sql 或 <版本号>_<名称>.down.sql
+var sqlFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// FromFS 读取 fsys 中 dir 目录下的 SQL 迁移文件，通常与 embed.FS 一起使用：
+//
+//	//go:embed migrations/*.sql
+//	var migrationFS embed.FS
+//
+//	migrations, err := migrate.FromFS(migrationFS, "migrations")
+//
+// 文件名为 <版本号>_<名称>.up.sql 与 <版本号>_<名称>.down.sql（如 0001_create_users.up.sql），
+// down 文件可选，其他扩展名的文件被忽略。
+// 文件中的语句以行尾的分号分隔并逐条执行，因此不依赖驱动的多语句支持；
+// 存储过程等语句体中含有行尾分号的迁移需要使用 Go 函数编写。
+func FromFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read %s: %w", dir, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	var versions []int64
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := sqlFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migrate: invalid migration file name %q, want <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: invalid migration version in %q: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("migrate: read %s: %w", entry.Name(), err)
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mg
+			versions = append(versions, version)
+		} else if mg.Name != match[2] {
+			return nil, fmt.Errorf("migrate: migration %d has different names %q and %q", version, mg.Name, match[2])
+		}
+
+		exec := execSQL(splitStatements(string(content)))
+		if match[3] == "up" {
+			mg.Up = exec
+		} else {
+			mg.Down = exec
+		}
+	}
+
+	migrations := make([]Migration, 0, len(versions))
+	for _, version := range versions {
+		mg := byVersion[version]
+		if mg.Up == nil {
+			return nil, fmt.Errorf("migrate: migration %d %s has no .up.sql file", mg.Version, mg.Name)
+		}
+		migrations = append(migrations, *mg)
+	}
+	return migrations, nil
+}
+
+// execSQL 返回依次执行 statements 的迁移函数
+func execSQL(statements []string) func(ctx context.Context, tx *gorm.DB) error {
+	return func(ctx context.Context, tx *gorm.DB) error {
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// splitStatements 以行尾的分号分隔 SQL 语句，忽略空语句与只有 -- 注释的语句
+func splitStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		current.Reset()
+		if stmt != "" && !commentOnly(stmt) {
+			statements = append(statements, stmt)
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			flush()
+		}
+	}
+	flush()
+	return statements
+}
+
+// commentOnly 判断语句是否只包含 -- 注释
+func commentOnly(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+// TestFromFS 测试读取 SQL 迁移文件并执行
+func TestFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0001_create_users.up.sql": {Data: []byte(`
+-- 用户表
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY,
+    name TEXT
+);
+CREATE INDEX idx_users_name ON users (name);
+`)},
+		"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;\n")},
+		"migrations/0002_seed.up.sql":           {Data: []byte("INSERT INTO users (name) VALUES ('alice');")},
+		"migrations/README.md":                  {Data: []byte("ignored")},
+	}
+
+	migrations, err := FromFS(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("FromFS() 失败: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "create_users" || migrations[1].Version != 2 {
+		t.Fatalf("FromFS() = %+v", migrations)
+	}
+	if migrations[0].Down == nil || migrations[1].Down != nil {
+		t.Error("只有提供了 .down.sql 的版本才有 Down")
+	}
+
+	ctx := context.Background()
+	db := openDB(t)
+	m, _ := New(Config{Migrations: migrations})
+	if _, err := m.Up(ctx, db); err != nil {
+		t.Fatalf("Up() 失败: %v", err)
+	}
+	var count int64
+	db.Table("users").Count(&count)
+	if count != 1 {
+		t.Errorf("users 行数 = %d, 期望 1", count)
+	}
+
+	bad := fstest.MapFS{"migrations/create_users.up.sql": {Data: []byte("SELECT 1;")}}
+	if _, err := FromFS(bad, "migrations"); err == nil {
+		t.Error("文件名缺少版本号时应返回错误")
+	}
+	noUp := fstest.MapFS{"migrations/0001_a.down.sql": {Data: []byte("SELECT 1;")}}
+	if _, err := FromFS(noUp, "migrations"); err == nil {
+		t.Error("缺少 .up.sql 时应返回错误")
+	}
+}
+
+// TestSplitStatements 测试按行尾分号分隔语句
+func TestSplitStatements(t *testing.T) {
+	got := splitStatements("-- comment\nCREATE TABLE a (x TEXT DEFAULT 'a;b');\n\nINSERT INTO a VALUES ('1');\n-- trailing\n")
+	if len(got) != 2 {
+		t.Fatalf("splitStatements() = %q, 期望 2 条语句", got)
+	}
+	if got[1] != "INSERT INTO a VALUES ('1');" {
+		t.Errorf("第二条语句 = %q", got[1])
+	}
+}
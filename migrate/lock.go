@@ -0,0 +1,145 @@
+package migrate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrLocked 在 LockTimeout 内未能获取迁移锁时返回此错误，说明其他执行者正在迁移同一个数据库。
+var ErrLocked = errors.New("migrate: migrations are locked by another runner")
+
+// lockID 锁表中唯一一行的主键
+const lockID = 1
+
+// lockPollInterval 等待锁时的重试间隔
+var lockPollInterval = 100 * time.Millisecond
+
+// lockRow 锁表的一行。锁通过插入主键固定的行获取，主键冲突说明锁已被持有，
+// 不依赖 GET_LOCK、pg_advisory_lock 等数据库特有的功能。
+type lockRow struct {
+	ID       int    `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string `gorm:"size:128"`
+	LockedAt time.Time
+}
+
+// errLockLost 持有的迁移锁因续期失败过期并被其他执行者抢占
+var errLockLost = errors.New("migrate: lock expired and was taken by another runner")
+
+// withLock 获取 db 的迁移锁后执行 fn，fn 返回后释放锁。ctx 被取消时仍会释放锁。
+//
+// 迁移记录表在获取锁之后再次检查并创建，避免与其他执行者并发建表。
+// fn 执行期间每隔 LockTTL/3 续期一次锁，耗时超过 LockTTL 的迁移不会被其他执行者视为过期而抢占；
+// 续期时发现锁已被抢占则取消 fn 使用的 ctx 与 db。
+func (m *Migrator) withLock(ctx context.Context, db *gorm.DB, fn func(ctx context.Context, db *gorm.DB) error) error {
+	db = db.WithContext(ctx)
+	if err := ensureTable(db, m.lockTableOf(db), &lockRow{}); err != nil {
+		return err
+	}
+
+	owner := lockOwner()
+	if err := m.lock(ctx, db, owner); err != nil {
+		return err
+	}
+	defer m.unlock(db.WithContext(context.WithoutCancel(ctx)), owner)
+
+	if err := ensureTable(db, m.tableOf(db), &record{}); err != nil {
+		return err
+	}
+
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.heartbeat(lockCtx, db, owner, stop, cancel)
+	}()
+
+	err := fn(lockCtx, db.WithContext(lockCtx))
+	close(stop)
+	<-done
+	if err != nil && errors.Is(context.Cause(lockCtx), errLockLost) {
+		return fmt.Errorf("%w: %w", errLockLost, err)
+	}
+	return err
+}
+
+// heartbeat 每隔 LockTTL/3 更新 owner 持有的锁的 locked_at，直到 stop 被关闭；
+// 锁已不属于 owner 时以 errLockLost 调用 cancel。更新出错（如数据库繁忙）时等待下次续期。
+func (m *Migrator) heartbeat(ctx context.Context, db *gorm.DB, owner string, stop <-chan struct{}, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(max(m.lockTTL/3, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		result := db.WithContext(ctx).Table(m.lockTableOf(db)).
+			Where("id = ? AND owner = ?", lockID, owner).
+			Update("locked_at", time.Now())
+		if result.Error == nil && result.RowsAffected == 0 {
+			cancel(errLockLost)
+			return
+		}
+	}
+}
+
+// lock 获取迁移锁，锁已被持有时每隔 lockPollInterval 重试，直到超过 LockTimeout。
+// 持有超过 LockTTL 的锁视为执行者已退出，会被清除。
+func (m *Migrator) lock(ctx context.Context, db *gorm.DB, owner string) error {
+	// 锁被持有时插入会失败，不把这类错误记录到日志
+	quiet := db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Silent)})
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		err := db.Table(m.lockTableOf(db)).
+			Where("id = ? AND locked_at < ?", lockID, time.Now().Add(-m.lockTTL)).
+			Delete(&lockRow{}).Error
+		if err != nil {
+			return fmt.Errorf("migrate: clear expired lock: %w", err)
+		}
+
+		insertErr := quiet.Table(m.lockTableOf(db)).Create(&lockRow{ID: lockID, Owner: owner, LockedAt: time.Now()}).Error
+		if insertErr == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			var holder lockRow
+			if err := db.Table(m.lockTableOf(db)).Where("id = ?", lockID).Limit(1).Find(&holder).Error; err != nil || holder.Owner == "" {
+				// 锁未被持有，插入失败是其他原因
+				return fmt.Errorf("migrate: acquire lock: %w", insertErr)
+			}
+			return fmt.Errorf("%w: held by %s since %s", ErrLocked, holder.Owner, holder.LockedAt.Format(time.RFC3339))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// unlock 释放 owner 持有的迁移锁
+func (m *Migrator) unlock(db *gorm.DB, owner string) {
+	_ = db.Table(m.lockTableOf(db)).Where("id = ? AND owner = ?", lockID, owner).Delete(&lockRow{}).Error
+}
+
+// lockOwner 返回标识本次执行的锁持有者：主机名、进程号与随机数
+func lockOwner() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
@@ -0,0 +1,160 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TestMigrator_Lock 测试锁被持有时等待超时，过期的锁可以被抢占
+func TestMigrator_Lock(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m, _ := New(Config{Migrations: []Migration{createTable(1, "users")}, LockTimeout: 50 * time.Millisecond, LockTTL: time.Hour})
+	if err := m.ensureTables(db); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟其他执行者持有锁
+	db.Table(m.lockTableOf(db)).Create(&lockRow{ID: lockID, Owner: "other", LockedAt: time.Now()})
+	if _, err := m.Up(ctx, db); !errors.Is(err, ErrLocked) {
+		t.Fatalf("锁被持有时应返回 ErrLocked，实际为: %v", err)
+	}
+
+	// 锁超过 LockTTL 后视为过期
+	db.Table(m.lockTableOf(db)).Where("id = ?", lockID).Update("locked_at", time.Now().Add(-2*time.Hour))
+	if _, err := m.Up(ctx, db); err != nil {
+		t.Fatalf("过期的锁应被抢占: %v", err)
+	}
+	var n int64
+	db.Table(m.lockTableOf(db)).Count(&n)
+	if n != 0 {
+		t.Error("迁移完成后应释放锁")
+	}
+}
+
+// TestMigrator_ConcurrentUp 测试多个执行者并发迁移同一数据库时每个版本只执行一次
+func TestMigrator_ConcurrentUp(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+
+	var runs int32
+	m, _ := New(Config{Migrations: []Migration{{
+		Version: 1,
+		Name:    "slow",
+		Up: func(ctx context.Context, tx *gorm.DB) error {
+			atomic.AddInt32(&runs, 1)
+			time.Sleep(50 * time.Millisecond)
+			return tx.Exec("CREATE TABLE slow (id INTEGER)").Error
+		},
+	}}})
+	if err := m.ensureTables(db); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Up(ctx, db); err != nil {
+				t.Errorf("Up() 失败: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("迁移执行次数 = %d, 期望 1", n)
+	}
+}
+
+// TestMigrator_ConcurrentUpNewDatabase 测试多个执行者在新数据库上并发建表、迁移
+func TestMigrator_ConcurrentUpNewDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m, _ := New(Config{Migrations: []Migration{createTable(1, "users")}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Up(ctx, db); err != nil {
+				t.Errorf("Up() 失败: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if versions, _ := m.Applied(ctx, db); len(versions) != 1 {
+		t.Errorf("Applied() = %v, 期望 [1]", versions)
+	}
+}
+
+// TestMigrator_LockHeartbeat 测试迁移耗时超过 LockTTL 时锁会续期，不会被其他执行者抢占
+func TestMigrator_LockHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+
+	started := make(chan struct{})
+	m, _ := New(Config{
+		Migrations: []Migration{{
+			Version: 1,
+			Name:    "slow",
+			Up: func(ctx context.Context, tx *gorm.DB) error {
+				close(started)
+				time.Sleep(300 * time.Millisecond)
+				return nil
+			},
+		}},
+		LockTimeout: 150 * time.Millisecond,
+		LockTTL:     60 * time.Millisecond,
+	})
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := m.Up(ctx, db)
+		errc <- err
+	}()
+	<-started
+	if _, err := m.Up(ctx, db); !errors.Is(err, ErrLocked) {
+		t.Errorf("迁移执行期间锁应被续期，其他执行者应返回 ErrLocked，实际为: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Up() 失败: %v", err)
+	}
+}
+
+// TestMigrator_LockLost 测试锁被其他执行者抢占后取消正在执行的迁移
+func TestMigrator_LockLost(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+
+	m, _ := New(Config{
+		Migrations: []Migration{{
+			Version: 1,
+			Name:    "slow",
+			Up: func(ctx context.Context, tx *gorm.DB) error {
+				// 模拟锁过期后被其他执行者抢占
+				db.Table(DefaultTable+"_lock").Where("id = ?", lockID).Update("owner", "other")
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(time.Second):
+					return nil
+				}
+			},
+		}},
+		LockTTL: 30 * time.Millisecond,
+	})
+	if _, err := m.Up(ctx, db); !errors.Is(err, errLockLost) {
+		t.Errorf("锁被抢占时应返回 errLockLost，实际为: %v", err)
+	}
+	if versions, _ := m.Applied(ctx, db); len(versions) != 0 {
+		t.Errorf("被取消的迁移不应记录，实际为 %v", versions)
+	}
+}
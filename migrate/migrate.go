@@ -0,0 +1,268 @@
+// Package migrate 为 mgorm 管理的数据库提供版本化的 schema 迁移。
+//
+// 迁移可以是 Go 函数，也可以是通过 embed.FS 嵌入的 .sql 文件（参见 FromFS）。
+// 每个数据库在迁移记录表中记录已执行的版本，并通过锁表避免多个实例同时执行迁移：
+//
+//	m, err := migrate.New(migrate.Config{Migrations: migrations})
+//	report, err := migrate.MigrateAll(ctx, group, migrate.MigrateAllOptions{Migrator: m, Concurrency: 4})
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// DefaultTable 是 Config.Table 为空时使用的迁移记录表名
+	DefaultTable = "schema_migrations"
+	// DefaultLockTimeout 是 Config.LockTimeout 为 0 时等待锁的最长时间
+	DefaultLockTimeout = time.Minute
+	// DefaultLockTTL 是 Config.LockTTL 为 0 时锁的有效期
+	DefaultLockTTL = 15 * time.Minute
+)
+
+// Migration 一个版本的迁移
+type Migration struct {
+	// Version 版本号，按升序执行，同一 Migrator 中不可重复
+	Version int64
+	// Name 迁移名称，记录在迁移记录表中
+	Name string
+	// Up 执行迁移（必需），在事务中调用，tx 已绑定 ctx
+	Up func(ctx context.Context, tx *gorm.DB) error
+	// Down 回滚迁移（可选），在事务中调用，为 nil 时该版本不能回滚
+	Down func(ctx context.Context, tx *gorm.DB) error
+}
+
+// Config 迁移配置
+type Config struct {
+	// Migrations 迁移列表，顺序不限
+	Migrations []Migration
+	// Table 迁移记录表名，为空时使用 DefaultTable；锁表为 Table + "_lock"。
+	// 两个表名都会加上连接命名策略的表前缀（TablePrefix，SharedPool 模式下的 schema）
+	Table string
+	// LockTimeout 等待其他执行者释放锁的最长时间，为 0 时使用 DefaultLockTimeout
+	LockTimeout time.Duration
+	// LockTTL 锁的有效期，超过该时间仍未释放的锁视为执行者已退出，可以被抢占；为 0 时使用 DefaultLockTTL
+	LockTTL time.Duration
+}
+
+// Migrator 对单个数据库执行迁移，可以并发用于多个数据库。
+type Migrator struct {
+	migrations  []Migration
+	table       string
+	lockTable   string
+	lockTimeout time.Duration
+	lockTTL     time.Duration
+}
+
+// record 迁移记录表的一行
+type record struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+// New 创建 Migrator，版本号重复或缺少 Up 时返回错误。
+func New(cfg Config) (*Migrator, error) {
+	if cfg.LockTimeout < 0 || cfg.LockTTL < 0 {
+		return nil, errors.New("migrate: LockTimeout and LockTTL must not be negative")
+	}
+
+	migrations := slices.Clone(cfg.Migrations)
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	for i, mg := range migrations {
+		if mg.Up == nil {
+			return nil, fmt.Errorf("migrate: migration %d has no Up", mg.Version)
+		}
+		if i > 0 && migrations[i-1].Version == mg.Version {
+			return nil, fmt.Errorf("migrate: duplicate migration version %d", mg.Version)
+		}
+	}
+
+	m := &Migrator{
+		migrations:  migrations,
+		table:       cfg.Table,
+		lockTimeout: cfg.LockTimeout,
+		lockTTL:     cfg.LockTTL,
+	}
+	if m.table == "" {
+		m.table = DefaultTable
+	}
+	if m.lockTimeout == 0 {
+		m.lockTimeout = DefaultLockTimeout
+	}
+	if m.lockTTL == 0 {
+		m.lockTTL = DefaultLockTTL
+	}
+	m.lockTable = m.table + "_lock"
+	return m, nil
+}
+
+// Migrations 返回按版本号升序排列的迁移列表
+func (m *Migrator) Migrations() []Migration {
+	return slices.Clone(m.migrations)
+}
+
+// Applied 返回 db 中已执行的版本号，按升序排列。只读取迁移记录表，不会创建该表：表不存在时返回空列表。
+func (m *Migrator) Applied(ctx context.Context, db *gorm.DB) ([]int64, error) {
+	db = db.WithContext(ctx)
+	if !db.Migrator().HasTable(m.tableOf(db)) {
+		return nil, nil
+	}
+	return m.applied(db)
+}
+
+// Pending 返回 db 中尚未执行的迁移，按版本号升序排列
+func (m *Migrator) Pending(ctx context.Context, db *gorm.DB) ([]Migration, error) {
+	applied, err := m.Applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return m.pending(applied), nil
+}
+
+// Up 按版本号升序执行 db 中尚未执行的迁移，返回本次执行的版本号。
+// 每个迁移在单独的事务中执行并写入迁移记录，失败时停止并返回已执行的版本号与错误。
+func (m *Migrator) Up(ctx context.Context, db *gorm.DB) (applied []int64, err error) {
+	err = m.withLock(ctx, db, func(ctx context.Context, db *gorm.DB) error {
+		done, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		for _, mg := range m.pending(done) {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := mg.Up(ctx, tx); err != nil {
+					return err
+				}
+				return tx.Table(m.tableOf(tx)).Create(&record{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: up %d %s: %w", mg.Version, mg.Name, err)
+			}
+			applied = append(applied, mg.Version)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down 按版本号降序回滚 db 中最近执行的 steps 个迁移，返回本次回滚的版本号。
+// 迁移记录中的版本不在当前迁移列表中或没有 Down 时返回错误。
+func (m *Migrator) Down(ctx context.Context, db *gorm.DB, steps int) (reverted []int64, err error) {
+	if steps <= 0 {
+		return nil, nil
+	}
+	err = m.withLock(ctx, db, func(ctx context.Context, db *gorm.DB) error {
+		done, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		for i := len(done) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mg, ok := m.find(done[i])
+			if !ok {
+				return fmt.Errorf("migrate: down %d: unknown migration", done[i])
+			}
+			if mg.Down == nil {
+				return fmt.Errorf("migrate: down %d %s: migration has no Down", mg.Version, mg.Name)
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := mg.Down(ctx, tx); err != nil {
+					return err
+				}
+				return tx.Table(m.tableOf(tx)).Where("version = ?", mg.Version).Delete(&record{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: down %d %s: %w", mg.Version, mg.Name, err)
+			}
+			reverted = append(reverted, mg.Version)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// ensureTables 创建迁移记录表与锁表
+func (m *Migrator) ensureTables(db *gorm.DB) error {
+	if err := ensureTable(db, m.lockTableOf(db), &lockRow{}); err != nil {
+		return err
+	}
+	return ensureTable(db, m.tableOf(db), &record{})
+}
+
+// ensureTable 创建表 name，已存在时不做处理。
+// 多个执行者可能同时发现表不存在并创建，因此创建失败后表已存在（被其他执行者创建）同样视为成功。
+func ensureTable(db *gorm.DB, name string, model any) error {
+	if db.Migrator().HasTable(name) {
+		return nil
+	}
+	if err := db.Table(name).Migrator().CreateTable(model); err != nil && !db.Migrator().HasTable(name) {
+		return fmt.Errorf("migrate: create table %s: %w", name, err)
+	}
+	return nil
+}
+
+// tableOf 返回 db 中的迁移记录表名。
+// 表名加上 db 命名策略的表前缀（Config.TablePrefix，SharedPool 模式下还包含 schema），
+// 共享同一数据库的不同租户各自使用独立的迁移记录表与锁表。
+func (m *Migrator) tableOf(db *gorm.DB) string {
+	return tablePrefix(db) + m.table
+}
+
+// lockTableOf 返回 db 中的锁表名，前缀规则与 tableOf 相同
+func (m *Migrator) lockTableOf(db *gorm.DB) string {
+	return tablePrefix(db) + m.lockTable
+}
+
+// tablePrefix 返回 db 命名策略中的表前缀，自定义命名策略没有前缀
+func tablePrefix(db *gorm.DB) string {
+	switch ns := db.NamingStrategy.(type) {
+	case schema.NamingStrategy:
+		return ns.TablePrefix
+	case *schema.NamingStrategy:
+		if ns != nil {
+			return ns.TablePrefix
+		}
+	}
+	return ""
+}
+
+// applied 查询已执行的版本号
+func (m *Migrator) applied(db *gorm.DB) ([]int64, error) {
+	var versions []int64
+	table := m.tableOf(db)
+	if err := db.Table(table).Order("version").Pluck("version", &versions).Error; err != nil {
+		return nil, fmt.Errorf("migrate: query %s: %w", table, err)
+	}
+	return versions, nil
+}
+
+// pending 返回不在 applied 中的迁移
+func (m *Migrator) pending(applied []int64) []Migration {
+	var pending []Migration
+	for _, mg := range m.migrations {
+		if _, found := slices.BinarySearch(applied, mg.Version); !found {
+			pending = append(pending, mg)
+		}
+	}
+	return pending
+}
+
+// find 按版本号查找迁移
+func (m *Migrator) find(version int64) (Migration, bool) {
+	i, found := slices.BinarySearchFunc(m.migrations, version, func(mg Migration, v int64) int {
+		return cmp.Compare(mg.Version, v)
+	})
+	if !found {
+		return Migration{}, false
+	}
+	return m.migrations[i], true
+}
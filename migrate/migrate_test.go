@@ -0,0 +1,136 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/qq1060656096/mgorm"
+	_ "github.com/qq1060656096/mgorm/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openDB 打开一个临时 SQLite 数据库
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	ctx := context.Background()
+	group := mgorm.New()
+	t.Cleanup(func() { group.Close(ctx) })
+	group.Register(ctx, "db", mgorm.DBConfig{DriverType: "sqlite", DBName: filepath.Join(t.TempDir(), "db.sqlite")})
+	return group.MustGet(ctx, "db")
+}
+
+// createTable 返回创建、删除表 name 的迁移
+func createTable(version int64, name string) Migration {
+	return Migration{
+		Version: version,
+		Name:    "create_" + name,
+		Up: func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Exec(fmt.Sprintf("CREATE TABLE %s (id INTEGER PRIMARY KEY)", name)).Error
+		},
+		Down: func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE " + name).Error
+		},
+	}
+}
+
+// TestMigrator_UpDown 测试按版本执行、跳过已执行的版本以及回滚
+func TestMigrator_UpDown(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+
+	m, err := New(Config{Migrations: []Migration{createTable(2, "orders"), createTable(1, "users")}})
+	if err != nil {
+		t.Fatalf("New() 失败: %v", err)
+	}
+	applied, err := m.Up(ctx, db)
+	if err != nil {
+		t.Fatalf("Up() 失败: %v", err)
+	}
+	if fmt.Sprint(applied) != "[1 2]" {
+		t.Errorf("Up() 执行的版本 = %v, 期望 [1 2]", applied)
+	}
+	if !db.Migrator().HasTable("users") || !db.Migrator().HasTable("orders") {
+		t.Fatal("迁移应创建 users 与 orders 表")
+	}
+
+	// 新增迁移后只执行新增的版本
+	m, _ = New(Config{Migrations: []Migration{createTable(1, "users"), createTable(2, "orders"), createTable(3, "goods")}})
+	if pending, _ := m.Pending(ctx, db); len(pending) != 1 || pending[0].Version != 3 {
+		t.Errorf("Pending() = %v, 期望只有版本 3", pending)
+	}
+	if applied, err := m.Up(ctx, db); err != nil || fmt.Sprint(applied) != "[3]" {
+		t.Errorf("Up() = %v, %v, 期望 [3]", applied, err)
+	}
+
+	reverted, err := m.Down(ctx, db, 2)
+	if err != nil {
+		t.Fatalf("Down() 失败: %v", err)
+	}
+	if fmt.Sprint(reverted) != "[3 2]" {
+		t.Errorf("Down() 回滚的版本 = %v, 期望 [3 2]", reverted)
+	}
+	if db.Migrator().HasTable("orders") || !db.Migrator().HasTable("users") {
+		t.Error("应只回滚 goods 与 orders")
+	}
+	if versions, _ := m.Applied(ctx, db); fmt.Sprint(versions) != "[1]" {
+		t.Errorf("Applied() = %v, 期望 [1]", versions)
+	}
+}
+
+// TestMigrator_PendingReadOnly 测试 Applied、Pending 不会创建迁移记录表与锁表
+func TestMigrator_PendingReadOnly(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+
+	m, _ := New(Config{Migrations: []Migration{createTable(1, "users"), createTable(2, "orders")}})
+	pending, err := m.Pending(ctx, db)
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("Pending() = %v, %v, 期望全部 2 个迁移", pending, err)
+	}
+	if db.Migrator().HasTable(m.tableOf(db)) || db.Migrator().HasTable(m.lockTableOf(db)) {
+		t.Error("Pending() 不应创建迁移记录表与锁表")
+	}
+}
+
+// TestMigrator_UpError 测试迁移失败时事务回滚，之前的版本保留
+func TestMigrator_UpError(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	boom := errors.New("boom")
+
+	m, _ := New(Config{Migrations: []Migration{
+		createTable(1, "users"),
+		{Version: 2, Name: "broken", Up: func(ctx context.Context, tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE broken (id INTEGER)").Error; err != nil {
+				return err
+			}
+			return boom
+		}},
+	}})
+	applied, err := m.Up(ctx, db)
+	if !errors.Is(err, boom) {
+		t.Fatalf("应返回迁移的错误，实际为: %v", err)
+	}
+	if fmt.Sprint(applied) != "[1]" {
+		t.Errorf("失败前执行的版本 = %v, 期望 [1]", applied)
+	}
+	if db.Migrator().HasTable("broken") {
+		t.Error("失败的迁移应在事务中回滚")
+	}
+	if versions, _ := m.Applied(ctx, db); fmt.Sprint(versions) != "[1]" {
+		t.Errorf("Applied() = %v, 期望 [1]", versions)
+	}
+}
+
+// TestNew_Errors 测试无效的迁移列表
+func TestNew_Errors(t *testing.T) {
+	if _, err := New(Config{Migrations: []Migration{createTable(1, "a"), createTable(1, "b")}}); err == nil {
+		t.Error("版本号重复时应返回错误")
+	}
+	if _, err := New(Config{Migrations: []Migration{{Version: 1}}}); err == nil {
+		t.Error("缺少 Up 时应返回错误")
+	}
+}
@@ -0,0 +1,276 @@
+package mgorm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+)
+
+// ReloadEventType 配置变更事件类型
+type ReloadEventType string
+
+// 配置变更事件类型
+const (
+	ReloadAdded      ReloadEventType = "added"       // ReloadAdded 注册了配置中新增的连接
+	ReloadRemoved    ReloadEventType = "removed"     // ReloadRemoved 注销了配置中删除的连接
+	ReloadReplaced   ReloadEventType = "replaced"    // ReloadReplaced 配置变化的连接替换为新的注册与连接池
+	ReloadFailed     ReloadEventType = "failed"      // ReloadFailed 变更无效或新配置无法连接，所在组的变更全部回滚
+	ReloadRolledBack ReloadEventType = "rolled_back" // ReloadRolledBack 同组的其他变更失败，该变更未应用或已撤销
+)
+
+// ReloadEvent 应用配置时一个连接的变更事件
+type ReloadEvent struct {
+	Type  ReloadEventType // Type 事件类型
+	Group string          // Group 组名
+	Name  string          // Name 连接名
+	Err   error           // Err 失败原因，ReloadRolledBack 时为导致回滚的错误
+	// RevertErr 撤销已应用的变更失败时的错误（仅 ReloadRolledBack），连接的实际状态参见 ReloadReport.Applied
+	RevertErr error
+}
+
+// ApplyOptions ApplyManagerConfig 的选项
+type ApplyOptions struct {
+	// DrainTimeout 被替换或注销的连接池的最长排空时间，为 0 时使用 DefaultRotateDrainTimeout，参见 RotateOptions
+	DrainTimeout time.Duration
+}
+
+// ReloadReport 应用配置的结果
+type ReloadReport struct {
+	Events  []ReloadEvent // Events 按组名排序，组内依次为替换、注册、注销
+	Applied ManagerConfig // Applied 实际生效的配置：成功的组为新配置，回滚的组为原配置，撤销失败的连接为实际生效的配置
+}
+
+// Err 返回合并后的错误，每个错误以失败或撤销失败的 group/name 开头；没有失败时返回 nil
+func (r ReloadReport) Err() error {
+	var errs []error
+	for _, event := range r.Events {
+		if event.Type == ReloadFailed {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", event.Group, event.Name, event.Err))
+		}
+		if event.RevertErr != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: revert: %w", event.Group, event.Name, event.RevertErr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyManagerConfig 把 next 应用到 m，prev 为上次应用的配置（通常是 LoadManager 使用的配置或上次返回的 ReloadReport.Applied）：
+//   - next 中的连接与 prev 中的配置比较：未注册的注册，配置变化的替换；配置未变化的连接保持当前的注册，
+//     因此 Rotate 等在运行期间更新的配置不会被还原。不在 prev 中但已注册的连接与当前注册的配置比较
+//   - prev 中有而 next 中没有的连接被注销；不在 prev 中的连接（如 RegisterToDB 注册的租户库）不受影响
+//   - prev 中有而 next 中没有的组，其中的连接注销后不再包含任何连接时，该组从 m 中删除（m 由 NewManager 创建时）
+//
+// 已打开的连接替换前新配置先打开并 Ping 一次，之后原子地替换注册，旧连接池排空后关闭，并立即打开新连接池；
+// 尚未打开的连接只替换注册，与新增的连接一样惰性打开。
+//
+// 每个组的变更作为一个整体：任一配置无效或无法连接时，该组已应用的变更被撤销、其余变更不再应用，
+// 其他组不受影响。撤销失败时错误记录在 ReloadRolledBack 事件的 RevertErr 与返回的错误中，
+// Applied 中该连接为实际生效的配置（通常是变更后的配置）。比较配置时只比较可序列化的字段，DSN 按 AutoDsn 补全后比较。
+func ApplyManagerConfig(ctx context.Context, m Manager, prev, next ManagerConfig, opts ApplyOptions) (ReloadReport, error) {
+	groupNames := make(map[string]struct{}, len(next))
+	for groupName := range prev {
+		groupNames[groupName] = struct{}{}
+	}
+	for groupName := range next {
+		groupNames[groupName] = struct{}{}
+	}
+
+	report := ReloadReport{Applied: make(ManagerConfig, len(next))}
+	for _, groupName := range sortedKeys(groupNames) {
+		events, applied, ok := applyGroup(ctx, m, groupName, prev[groupName], next[groupName], opts)
+		report.Events = append(report.Events, events...)
+
+		if ok && applied == nil {
+			if mm, isManager := m.(*manager); isManager {
+				mm.removeGroup(groupName)
+			}
+		}
+		if applied != nil {
+			report.Applied[groupName] = applied
+		}
+	}
+	return report, report.Err()
+}
+
+// configChange 组内一个连接的变更
+type configChange struct {
+	typ  ReloadEventType
+	name string
+	cfg  DBConfig // cfg 新配置，ReloadAdded、ReloadReplaced 时有效
+	old  DBConfig // old 当前注册的配置，ReloadReplaced、ReloadRemoved 时有效
+}
+
+// applyGroup 应用一个组的变更，返回事件与该组实际生效的配置。
+// 失败时撤销已应用的变更并返回 false，生效的配置为 prev，撤销失败的连接参见 keepChange。
+func applyGroup(ctx context.Context, m Manager, groupName string, prev, next map[string]DBConfig, opts ApplyOptions) ([]ReloadEvent, map[string]DBConfig, bool) {
+	if len(next) > 0 {
+		m.AddGroup(groupName)
+	}
+	group, err := m.Group(groupName)
+	if err != nil {
+		// 组不存在且新配置中没有该组，没有需要注销的连接
+		return nil, next, true
+	}
+
+	changes := diffGroup(ctx, group, prev, next)
+	revertErrs := make([]error, len(changes))
+	fail := func(failed int, err error) ([]ReloadEvent, map[string]DBConfig, bool) {
+		applied := maps.Clone(prev)
+		events := make([]ReloadEvent, 0, len(changes))
+		for i, c := range changes {
+			event := ReloadEvent{Type: ReloadRolledBack, Group: groupName, Name: c.name, Err: err, RevertErr: revertErrs[i]}
+			if i == failed {
+				event.Type = ReloadFailed
+			}
+			if revertErrs[i] != nil {
+				applied = keepChange(ctx, group, applied, c, next)
+			}
+			events = append(events, event)
+		}
+		return events, applied, false
+	}
+
+	// 先校验所有新配置并确认替换已打开连接的配置能够连接，此时还没有修改任何注册
+	for i := range changes {
+		c := &changes[i]
+		if c.typ == ReloadRemoved {
+			continue
+		}
+		cfg, err := prepareConfig(c.cfg)
+		if err != nil {
+			return fail(i, err)
+		}
+		c.cfg = cfg
+		if _, opened := OpenedDB(group, c.name); c.typ == ReloadReplaced && opened {
+			if err := probeConfig(ctx, cfg); err != nil {
+				return fail(i, err)
+			}
+		}
+	}
+
+	for i, c := range changes {
+		if err := applyChange(ctx, group, c, opts.DrainTimeout); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				revertErrs[j] = revertChange(ctx, group, changes[j], opts.DrainTimeout)
+			}
+			return fail(i, err)
+		}
+	}
+
+	events := make([]ReloadEvent, 0, len(changes))
+	for _, c := range changes {
+		events = append(events, ReloadEvent{Type: c.typ, Group: groupName, Name: c.name})
+	}
+	return events, next, true
+}
+
+// keepChange 把撤销失败的变更 c 记入生效的配置 applied：注册的连接为 next 中的配置，注销的连接被删除；
+// 替换的连接已还原注册（只是重新打开失败）时保持原配置，否则为 next 中的配置
+func keepChange(ctx context.Context, group Group, applied map[string]DBConfig, c configChange, next map[string]DBConfig) map[string]DBConfig {
+	if applied == nil {
+		applied = make(map[string]DBConfig)
+	}
+	switch c.typ {
+	case ReloadAdded:
+		applied[c.name] = next[c.name]
+	case ReloadReplaced:
+		if current, err := group.Config(ctx, c.name); err != nil || !sameConfig(current, c.old) {
+			applied[c.name] = next[c.name]
+		}
+	case ReloadRemoved:
+		delete(applied, c.name)
+	}
+	return applied
+}
+
+// diffGroup 比较 next 与 prev，返回需要替换、注册与注销的连接，按此顺序排列。
+// 未注册的连接总是注册；不在 prev 中但已注册的连接与 group 中当前注册的配置比较。
+// 注销只针对 prev 中有而 next 中没有的连接。
+func diffGroup(ctx context.Context, group Group, prev, next map[string]DBConfig) []configChange {
+	var replaced, added, removed []configChange
+	for _, name := range sortedKeys(next) {
+		cfg := next[name]
+		current, err := group.Config(ctx, name)
+		if err != nil {
+			added = append(added, configChange{typ: ReloadAdded, name: name, cfg: cfg})
+			continue
+		}
+		base, ok := prev[name]
+		if !ok {
+			base = current
+		}
+		if !sameConfig(base, cfg) {
+			replaced = append(replaced, configChange{typ: ReloadReplaced, name: name, cfg: cfg, old: current})
+		}
+	}
+	for _, name := range sortedKeys(prev) {
+		if _, ok := next[name]; ok {
+			continue
+		}
+		if current, err := group.Config(ctx, name); err == nil {
+			removed = append(removed, configChange{typ: ReloadRemoved, name: name, old: current})
+		}
+	}
+	return append(append(replaced, added...), removed...)
+}
+
+// applyChange 应用一个变更
+func applyChange(ctx context.Context, group Group, c configChange, drainTimeout time.Duration) error {
+	switch c.typ {
+	case ReloadAdded:
+		isNew, err := group.Register(ctx, c.name, c.cfg)
+		if err == nil && !isNew {
+			err = fmt.Errorf("mgorm: %s is already registered", c.name)
+		}
+		return err
+	case ReloadReplaced:
+		return replaceConfig(ctx, group, c.name, c.cfg, drainTimeout)
+	case ReloadRemoved:
+		return unregisterDraining(ctx, group, c.name, drainTimeout)
+	}
+	return nil
+}
+
+// revertChange 撤销已应用的变更
+func revertChange(ctx context.Context, group Group, c configChange, drainTimeout time.Duration) error {
+	switch c.typ {
+	case ReloadAdded:
+		return group.Unregister(ctx, c.name)
+	case ReloadReplaced:
+		return replaceConfig(ctx, group, c.name, c.old, drainTimeout)
+	case ReloadRemoved:
+		isNew, err := group.Register(ctx, c.name, c.old)
+		if err == nil && !isNew {
+			err = fmt.Errorf("mgorm: %s is already registered", c.name)
+		}
+		return err
+	}
+	return nil
+}
+
+// replaceConfig 把 name 的注册替换为 cfg，旧连接已打开时立即打开新连接池
+func replaceConfig(ctx context.Context, group Group, name string, cfg DBConfig, drainTimeout time.Duration) error {
+	_, opened := OpenedDB(group, name)
+	if err := swapConfig(ctx, group, name, cfg, drainTimeout); err != nil {
+		return err
+	}
+	if !opened {
+		return nil
+	}
+	_, err := group.Get(ctx, name)
+	return err
+}
+
+// sameConfig 判断两个配置是否等价：比较可序列化的字段，DSN 按 AutoDsn 补全后比较，
+// 因此 LoadManager 注册时补全了 DSN 的配置与配置文件中的原始配置视为相同
+func sameConfig(a, b DBConfig) bool {
+	a.DSN = a.AutoDsn()
+	b.DSN = b.AutoDsn()
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
@@ -0,0 +1,339 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reloadTestConfig 返回 dir 下名为 name 的 SQLite 配置
+func reloadTestConfig(dir, name string) DBConfig {
+	return DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, name+".db")}
+}
+
+// TestApplyManagerConfig 测试新增、替换、注销连接，以及不在上次配置中的连接不受影响
+func TestApplyManagerConfig(t *testing.T) {
+	ctx := context.Background()
+	drainPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { drainPollInterval = 100 * time.Millisecond })
+	dir := t.TempDir()
+
+	prev := ManagerConfig{
+		"business": {
+			"same":    reloadTestConfig(dir, "same"),
+			"changed": reloadTestConfig(dir, "changed"),
+			"removed": reloadTestConfig(dir, "removed"),
+		},
+	}
+	manager, err := LoadManager(ctx, prev)
+	if err != nil {
+		t.Fatalf("LoadManager() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+	business := manager.MustGroup("business")
+	MustRegisterToDB(ctx, business, "same", "tenant", filepath.Join(dir, "tenant.db"))
+	same := business.MustGet(ctx, "same")
+	changed := business.MustGet(ctx, "changed")
+
+	changedCfg := reloadTestConfig(dir, "changed")
+	changedCfg.MaxOpenConns = 5
+	next := ManagerConfig{
+		"business": {
+			"same":    reloadTestConfig(dir, "same"),
+			"changed": changedCfg,
+			"added":   reloadTestConfig(dir, "added"),
+		},
+		"public": {
+			"common": reloadTestConfig(dir, "common"),
+		},
+	}
+
+	report, err := ApplyManagerConfig(ctx, manager, prev, next, ApplyOptions{})
+	if err != nil {
+		t.Fatalf("ApplyManagerConfig() 失败: %v", err)
+	}
+	var got []string
+	for _, event := range report.Events {
+		got = append(got, string(event.Type)+" "+event.Group+"/"+event.Name)
+	}
+	want := "replaced business/changed,added business/added,removed business/removed,added public/common"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Events = %q, 期望 %q", strings.Join(got, ","), want)
+	}
+	if len(report.Applied) != 2 || len(report.Applied["business"]) != 3 {
+		t.Errorf("Applied 应为新配置: %+v", report.Applied)
+	}
+
+	if business.MustGet(ctx, "same") != same {
+		t.Error("配置未变化的连接不应被替换")
+	}
+	replaced, ok := OpenedDB(business, "changed")
+	if !ok || replaced == changed {
+		t.Fatal("已打开的连接替换后应立即打开新连接池")
+	}
+	if sqlDB, _ := replaced.DB(); sqlDB.Stats().MaxOpenConnections != 5 {
+		t.Errorf("新连接池应使用新配置，MaxOpenConnections = %d", sqlDB.Stats().MaxOpenConnections)
+	}
+	if _, err := business.Config(ctx, "removed"); err == nil {
+		t.Error("配置中删除的连接应被注销")
+	}
+	if _, err := business.Config(ctx, "tenant"); err != nil {
+		t.Error("不在上次配置中的连接不应被注销")
+	}
+	if _, ok := OpenedDB(business, "added"); ok {
+		t.Error("新增的连接应惰性打开")
+	}
+	manager.MustGroup("public").MustGet(ctx, "common")
+
+	oldSQLDB, _ := changed.DB()
+	deadline := time.Now().Add(5 * time.Second)
+	for oldSQLDB.Ping() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("被替换的连接池应在排空后关闭")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 再次应用相同的配置没有变更
+	report, err = ApplyManagerConfig(ctx, manager, next, next, ApplyOptions{})
+	if err != nil || len(report.Events) != 0 {
+		t.Errorf("再次应用相同配置应没有变更: %+v, %v", report.Events, err)
+	}
+}
+
+// TestApplyManagerConfig_RollBack 测试替换的配置无法连接时回滚所在组，其他组不受影响
+func TestApplyManagerConfig_RollBack(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	prev := ManagerConfig{
+		"business": {
+			"a": reloadTestConfig(dir, "a"),
+			"b": reloadTestConfig(dir, "b"),
+		},
+	}
+	manager, err := LoadManager(ctx, prev)
+	if err != nil {
+		t.Fatalf("LoadManager() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+	business := manager.MustGroup("business")
+	a := business.MustGet(ctx, "a")
+	business.MustGet(ctx, "b") // 只有已打开的连接替换前会确认新配置能够连接
+
+	aCfg := reloadTestConfig(dir, "a")
+	aCfg.MaxOpenConns = 5
+	next := ManagerConfig{
+		"business": {
+			"a": aCfg,
+			"b": reloadTestConfig(filepath.Join(dir, "missing"), "b"),
+			"c": reloadTestConfig(dir, "c"),
+		},
+		"public": {
+			"common": reloadTestConfig(dir, "common"),
+		},
+	}
+
+	report, err := ApplyManagerConfig(ctx, manager, prev, next, ApplyOptions{})
+	if err == nil || !strings.HasPrefix(err.Error(), "business/b: ") {
+		t.Fatalf("错误应以失败的 group/name 开头，实际为: %v", err)
+	}
+	var got []string
+	for _, event := range report.Events {
+		got = append(got, string(event.Type)+" "+event.Group+"/"+event.Name)
+	}
+	want := "rolled_back business/a,failed business/b,rolled_back business/c,added public/common"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Events = %q, 期望 %q", strings.Join(got, ","), want)
+	}
+	if report.Events[0].Err == nil {
+		t.Error("rolled_back 事件应包含导致回滚的错误")
+	}
+
+	if business.MustGet(ctx, "a") != a {
+		t.Error("回滚的组中的连接不应被替换")
+	}
+	if cfg := business.MustConfig(ctx, "a"); cfg.MaxOpenConns != 0 {
+		t.Error("回滚的组应保留原配置")
+	}
+	if _, err := business.Config(ctx, "c"); err == nil {
+		t.Error("回滚的组不应注册新增的连接")
+	}
+	if report.Applied["business"]["b"].DBName != prev["business"]["b"].DBName {
+		t.Error("回滚的组 Applied 应为原配置")
+	}
+	if _, err := manager.MustGroup("public").Config(ctx, "common"); err != nil {
+		t.Error("其他组的变更应被应用")
+	}
+
+	// 无效的配置同样回滚
+	invalid := ManagerConfig{"business": {"a": {DriverType: "unknown", DSN: "x"}, "b": prev["business"]["b"]}}
+	report, _ = ApplyManagerConfig(ctx, manager, prev, invalid, ApplyOptions{})
+	if len(report.Events) != 1 || report.Events[0].Type != ReloadFailed {
+		t.Errorf("无效配置应返回 failed 事件: %+v", report.Events)
+	}
+}
+
+// revertFailingManager 返回 revertFailingGroup 的 Manager，用于模拟注册与注销失败
+type revertFailingManager struct {
+	Manager
+	failRegister, failUnregister string
+}
+
+// Group 返回包装后的组
+func (m revertFailingManager) Group(name string) (Group, error) {
+	group, err := m.Manager.Group(name)
+	if err != nil {
+		return nil, err
+	}
+	return revertFailingGroup{failingGroup: failingGroup{Group: group, failName: m.failRegister}, failUnregister: m.failUnregister}, nil
+}
+
+// revertFailingGroup 注册 failName、注销 failUnregister 时返回错误的 Group
+type revertFailingGroup struct {
+	failingGroup
+	failUnregister string
+}
+
+// Unregister 注销 failUnregister 时返回错误
+func (g revertFailingGroup) Unregister(ctx context.Context, name string) error {
+	if name == g.failUnregister {
+		return errors.New("unregister failed")
+	}
+	return g.Group.Unregister(ctx, name)
+}
+
+// TestApplyManagerConfig_RevertFailed 测试撤销失败时报告错误，Applied 中为连接实际生效的配置
+func TestApplyManagerConfig_RevertFailed(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	prev := ManagerConfig{"business": {"a": reloadTestConfig(dir, "a")}}
+	loaded, err := LoadManager(ctx, prev)
+	if err != nil {
+		t.Fatalf("LoadManager() 失败: %v", err)
+	}
+	defer loaded.Close(ctx)
+	manager := revertFailingManager{Manager: loaded, failRegister: "d", failUnregister: "c"}
+
+	next := ManagerConfig{"business": {
+		"a": reloadTestConfig(dir, "a"),
+		"c": reloadTestConfig(dir, "c"),
+		"d": reloadTestConfig(dir, "d"),
+	}}
+	report, err := ApplyManagerConfig(ctx, manager, prev, next, ApplyOptions{})
+	if err == nil || !strings.Contains(err.Error(), "business/c: revert: unregister failed") {
+		t.Fatalf("错误应包含撤销失败的连接，实际为: %v", err)
+	}
+	if len(report.Events) != 2 || report.Events[0].Type != ReloadRolledBack || report.Events[0].RevertErr == nil {
+		t.Fatalf("rolled_back 事件应包含撤销失败的错误: %+v", report.Events)
+	}
+	if report.Events[1].Type != ReloadFailed || report.Events[1].RevertErr != nil {
+		t.Errorf("failed 事件 = %+v", report.Events[1])
+	}
+
+	// c 撤销失败仍然注册，Applied 中记录为新配置；d 未注册
+	if _, err := loaded.MustGroup("business").Config(ctx, "c"); err != nil {
+		t.Error("撤销失败的连接应保持注册")
+	}
+	applied := report.Applied["business"]
+	if _, ok := applied["c"]; !ok {
+		t.Errorf("Applied 应包含撤销失败的连接: %v", applied)
+	}
+	if _, ok := applied["d"]; ok {
+		t.Errorf("Applied 不应包含未注册的连接: %v", applied)
+	}
+	if _, ok := prev["business"]["c"]; ok {
+		t.Error("ApplyManagerConfig 不应修改 prev")
+	}
+}
+
+// TestApplyManagerConfig_Snapshot 测试与上次应用的配置比较：运行期间轮换的配置不会被还原，
+// 尚未打开的连接替换时不确认能否连接，配置中删除的组被删除
+func TestApplyManagerConfig_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	prev := ManagerConfig{
+		"business": {
+			"rotated": reloadTestConfig(dir, "rotated"),
+			"lazy":    reloadTestConfig(dir, "lazy"),
+		},
+		"public": {
+			"common": reloadTestConfig(dir, "common"),
+		},
+	}
+	manager, err := LoadManager(ctx, prev)
+	if err != nil {
+		t.Fatalf("LoadManager() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+	business := manager.MustGroup("business")
+	if err := RotateWithOptions(ctx, business, "rotated", RotateOptions{Update: func(cfg *DBConfig) { cfg.MaxOpenConns = 3 }}); err != nil {
+		t.Fatalf("RotateWithOptions() 失败: %v", err)
+	}
+
+	// lazy 指向尚不存在的目录，但从未打开，惰性打开时才会失败
+	next := ManagerConfig{
+		"business": {
+			"rotated": reloadTestConfig(dir, "rotated"),
+			"lazy":    reloadTestConfig(filepath.Join(dir, "missing"), "lazy"),
+		},
+	}
+	report, err := ApplyManagerConfig(ctx, manager, prev, next, ApplyOptions{})
+	if err != nil {
+		t.Fatalf("ApplyManagerConfig() 失败: %v", err)
+	}
+	var got []string
+	for _, event := range report.Events {
+		got = append(got, string(event.Type)+" "+event.Group+"/"+event.Name)
+	}
+	if want := "replaced business/lazy,removed public/common"; strings.Join(got, ",") != want {
+		t.Errorf("Events = %q, 期望 %q", strings.Join(got, ","), want)
+	}
+	if cfg := business.MustConfig(ctx, "rotated"); cfg.MaxOpenConns != 3 {
+		t.Error("配置文件中未变化的连接应保留轮换后的配置")
+	}
+	if _, ok := OpenedDB(business, "lazy"); ok {
+		t.Error("尚未打开的连接替换后应惰性打开")
+	}
+
+	// 再次应用相同的配置仍不还原轮换后的配置
+	report, err = ApplyManagerConfig(ctx, manager, report.Applied, next, ApplyOptions{})
+	if err != nil || len(report.Events) != 0 {
+		t.Errorf("再次应用相同配置应没有变更: %+v, %v", report.Events, err)
+	}
+
+	if _, err := manager.Group("public"); err == nil {
+		t.Error("配置中删除的组应被删除")
+	}
+	if names := manager.ListGroupNames(); len(names) != 1 || names[0] != "business" {
+		t.Errorf("ListGroupNames() = %v, 期望 [business]", names)
+	}
+	next["public"] = map[string]DBConfig{"common": reloadTestConfig(dir, "common")}
+	if _, err := ApplyManagerConfig(ctx, manager, report.Applied, next, ApplyOptions{}); err != nil {
+		t.Fatalf("ApplyManagerConfig() 失败: %v", err)
+	}
+	manager.MustGroup("public").MustGet(ctx, "common")
+}
+
+// TestSameConfig 测试 LoadManager 补全 DSN 后的配置与原始配置视为相同
+func TestSameConfig(t *testing.T) {
+	raw := DBConfig{DriverType: "mysql", Host: "127.0.0.1", Port: 3306, User: "root", DBName: "app"}
+	registered, err := prepareConfig(raw)
+	if err != nil {
+		t.Fatalf("prepareConfig() 失败: %v", err)
+	}
+	if !sameConfig(registered, raw) {
+		t.Error("补全 DSN 与 Dialector 后的配置应与原始配置相同")
+	}
+
+	changed := raw
+	changed.Replicas = []ReplicaConfig{{Host: "127.0.0.2"}}
+	if sameConfig(registered, changed) {
+		t.Error("从库变化时配置应不同")
+	}
+}
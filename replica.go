@@ -0,0 +1,229 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// 读写分离时从库的选择策略，对应 DBConfig.ReplicaPolicy
+const (
+	ReplicaPolicyRandom     = "random"      // ReplicaPolicyRandom 随机选择（默认）
+	ReplicaPolicyRoundRobin = "round_robin" // ReplicaPolicyRoundRobin 轮询
+	ReplicaPolicyLeastConn  = "least_conn"  // ReplicaPolicyLeastConn 选择使用中连接数最少的从库
+)
+
+// ReplicaConfig 读写分离中的一个从库。
+//
+// 设置 DSN 时直接使用该 DSN；否则以主库配置为基础，用非空字段覆盖 Host、Port 等字段后通过 AutoDsn 生成 DSN。
+// 从库与主库使用相同的 DriverType 与连接池参数。
+type ReplicaConfig struct {
+	DSN      string `yaml:"dsn" json:"dsn" toml:"dsn" mapstructure:"dsn"`                     // 数据源名称（可选）
+	Host     string `yaml:"host" json:"host" toml:"host" mapstructure:"host"`                 // 数据库主机地址，为空时使用主库的值
+	Port     int    `yaml:"port" json:"port" toml:"port" mapstructure:"port"`                 // 数据库端口，为 0 时使用主库的值
+	User     string `yaml:"user" json:"user" toml:"user" mapstructure:"user"`                 // 数据库用户名，为空时使用主库的值
+	Password string `yaml:"password" json:"password" toml:"password" mapstructure:"password"` // 数据库密码，为空时使用主库的值
+	DBName   string `yaml:"db_name" json:"db_name" toml:"db_name" mapstructure:"db_name"`     // 数据库名称，为空时使用主库的值
+}
+
+// UsePrimary 返回强制使用主库的 db，用于写后立即读等需要读到最新数据的场景：
+//
+//	db.Create(&order)
+//	mgorm.UsePrimary(db).First(&order, order.ID)
+//
+// 未配置从库时原样生效，不影响查询。
+func UsePrimary(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Write)
+}
+
+// replicaConfigs 返回每个从库完整的 DBConfig。
+// 从库继承主库的驱动、连接池、GORM 与日志配置，但不继承 Dialector 与 Replicas。
+func (c *DBConfig) replicaConfigs() []DBConfig {
+	driverType := c.DriverType
+	if driverType == "" && c.Dialector != nil {
+		driverType = c.Dialector.Name()
+	}
+
+	cfgs := make([]DBConfig, 0, len(c.Replicas))
+	for _, r := range c.Replicas {
+		cfg := *c
+		cfg.DriverType = driverType
+		cfg.Dialector = nil
+		cfg.Replicas = nil
+		cfg.DSN = r.DSN
+		if r.Host != "" {
+			cfg.Host = r.Host
+		}
+		if r.Port != 0 {
+			cfg.Port = r.Port
+		}
+		if r.User != "" {
+			cfg.User = r.User
+		}
+		if r.Password != "" {
+			cfg.Password = r.Password
+		}
+		if r.DBName != "" {
+			cfg.DBName = r.DBName
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	return cfgs
+}
+
+// validateReplicas 检查从库选择策略以及每个从库能否得到 Dialector
+func (c *DBConfig) validateReplicas() error {
+	if _, err := c.replicaPolicy(); err != nil {
+		return err
+	}
+	if err := c.validateReplicaProbe(); err != nil {
+		return err
+	}
+	for i, cfg := range c.replicaConfigs() {
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("replicas[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// replicaPolicy 根据 ReplicaPolicy 创建 dbresolver.Policy
+func (c *DBConfig) replicaPolicy() (dbresolver.Policy, error) {
+	switch c.ReplicaPolicy {
+	case "", ReplicaPolicyRandom:
+		return dbresolver.RandomPolicy{}, nil
+	case ReplicaPolicyRoundRobin:
+		return dbresolver.StrictRoundRobinPolicy(), nil
+	case ReplicaPolicyLeastConn:
+		return dbresolver.PolicyFunc(leastConn), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown replica_policy %q (supported: %s, %s, %s)", errInvalidReplica,
+			c.ReplicaPolicy, ReplicaPolicyRandom, ReplicaPolicyRoundRobin, ReplicaPolicyLeastConn)
+	}
+}
+
+// leastConn 选择使用中连接数最少的连接池，无法获取统计信息的连接池视为空闲
+func leastConn(connPools []gorm.ConnPool) gorm.ConnPool {
+	best, bestInUse := connPools[0], -1
+	for _, connPool := range connPools {
+		inUse := 0
+		if sqlDB, ok := connPoolSQLDB(connPool); ok {
+			inUse = sqlDB.Stats().InUse
+		}
+		if bestInUse < 0 || inUse < bestInUse {
+			best, bestInUse = connPool, inUse
+		}
+	}
+	return best
+}
+
+// useReplicas 为 db 注册读写分离插件：写操作使用主库，读操作按 ReplicaPolicy 选择从库。
+// 设置了 ReplicaProbeInterval 时同时注册从库探测器，探测器在 startReplicaProbe 后开始工作。
+// 未配置从库时不做任何处理。
+func (c *DBConfig) useReplicas(ctx context.Context, db *gorm.DB) error {
+	if len(c.Replicas) == 0 {
+		return nil
+	}
+
+	policy, err := c.replicaPolicy()
+	if err != nil {
+		return err
+	}
+
+	cfgs := c.replicaConfigs()
+	dialectors := make([]gorm.Dialector, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		dialector, err := cfg.openDialector(ctx)
+		if err != nil {
+			return fmt.Errorf("replicas[%d]: %w", i, err)
+		}
+		dialectors = append(dialectors, dialector)
+	}
+
+	if c.ReplicaProbeInterval > 0 {
+		primary, err := db.DB()
+		if err != nil {
+			return err
+		}
+		prober := c.newReplicaProber(primary)
+		if err := db.Use(prober); err != nil {
+			return err
+		}
+		policy = prober.policy(policy)
+		for i, dialector := range dialectors {
+			dialectors[i] = replicaDialector{Dialector: dialector, prober: prober, index: i}
+		}
+	}
+
+	return db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   policy,
+	}))
+}
+
+// eachSQLDB 对 db 的主库以及所有从库的 *sql.DB 调用 fn，fn 返回错误时立即停止。
+func eachSQLDB(db *gorm.DB, fn func(sqlDB *sql.DB) error) error {
+	if plugin, ok := db.Config.Plugins[(&dbresolver.DBResolver{}).Name()]; ok {
+		if resolver, ok := plugin.(*dbresolver.DBResolver); ok {
+			// 读写分离插件中同一个连接池可能同时作为主库与从库出现
+			seen := make(map[*sql.DB]bool)
+			return resolver.Call(func(connPool gorm.ConnPool) error {
+				sqlDB, ok := connPoolSQLDB(connPool)
+				if !ok || seen[sqlDB] {
+					return nil
+				}
+				seen[sqlDB] = true
+				return fn(sqlDB)
+			})
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return fn(sqlDB)
+}
+
+// connPoolSQLDB 返回连接池底层的 *sql.DB
+func connPoolSQLDB(connPool gorm.ConnPool) (*sql.DB, bool) {
+	switch cp := connPool.(type) {
+	case *sql.DB:
+		return cp, true
+	case *replicaConn:
+		return cp.replica, true
+	default:
+		return nil, false
+	}
+}
+
+// startReplicaProbe 启动 db 上注册的从库探测器，未注册时不做任何处理
+func startReplicaProbe(db *gorm.DB) {
+	if prober, ok := replicaProberOf(db); ok {
+		prober.start()
+	}
+}
+
+// closeSQLDBs 停止从库探测后关闭 db 的主库以及所有从库连接，返回合并后的错误。
+// 共用其他连接的连接池的 db 不关闭连接池。
+func closeSQLDBs(db *gorm.DB) error {
+	if isSharedPool(db) {
+		return nil
+	}
+	if prober, ok := replicaProberOf(db); ok {
+		prober.close()
+	}
+
+	var errs []error
+	err := eachSQLDB(db, func(sqlDB *sql.DB) error {
+		if err := sqlDB.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		return nil
+	})
+	return errors.Join(append(errs, err)...)
+}
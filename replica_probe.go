@@ -0,0 +1,286 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// 从库探测的默认值
+const (
+	// DefaultReplicaEjectAfter 未设置 ReplicaEjectAfter 时，连续探测失败多少次后摘除从库
+	DefaultReplicaEjectAfter = 3
+	// DefaultReplicaReinstateAfter 未设置 ReplicaReinstateAfter 时，连续探测成功多少次后恢复从库
+	DefaultReplicaReinstateAfter = 1
+)
+
+// ReplicaEventType 从库事件类型
+type ReplicaEventType string
+
+// 从库事件类型
+const (
+	ReplicaEjected    ReplicaEventType = "ejected"    // ReplicaEjected 从库连续探测失败被摘除
+	ReplicaReinstated ReplicaEventType = "reinstated" // ReplicaReinstated 被摘除的从库探测成功后恢复
+)
+
+// ReplicaEvent 从库被摘除或恢复的事件，通过 DBConfig.OnReplicaEvent 通知
+type ReplicaEvent struct {
+	Type    ReplicaEventType // Type 事件类型
+	Group   string           // Group 组名
+	Name    string           // Name 连接名
+	Replica int              // Replica 从库在 DBConfig.Replicas 中的下标
+	Err     error            // Err 导致摘除的最后一次探测错误，恢复时为 nil
+}
+
+// validateReplicaProbe 检查从库探测参数
+func (c *DBConfig) validateReplicaProbe() error {
+	if c.ReplicaProbeInterval < 0 || c.ReplicaProbeTimeout < 0 || c.ReplicaEjectAfter < 0 || c.ReplicaReinstateAfter < 0 {
+		return fmt.Errorf("%w: replica probe settings must not be negative", errInvalidReplica)
+	}
+	return nil
+}
+
+// newReplicaProber 根据探测参数创建从库探测器，primary 为从库被摘除时转发到的主库连接池
+func (c *DBConfig) newReplicaProber(primary *sql.DB) *replicaProber {
+	p := &replicaProber{
+		group:          c.groupName,
+		name:           c.connName,
+		interval:       c.ReplicaProbeInterval,
+		timeout:        c.ReplicaProbeTimeout,
+		ejectAfter:     c.ReplicaEjectAfter,
+		reinstateAfter: c.ReplicaReinstateAfter,
+		onEvent:        c.OnReplicaEvent,
+		primary:        primary,
+		replicas:       make([]*replicaConn, len(c.Replicas)),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	if p.timeout == 0 {
+		p.timeout = p.interval
+	}
+	if p.ejectAfter == 0 {
+		p.ejectAfter = DefaultReplicaEjectAfter
+	}
+	if p.reinstateAfter == 0 {
+		p.reinstateAfter = DefaultReplicaReinstateAfter
+	}
+	return p
+}
+
+// replicaConn 可被摘除的从库连接池，摘除期间所有操作转发到主库
+type replicaConn struct {
+	replica *sql.DB     // replica 从库连接池
+	primary *sql.DB     // primary 主库连接池
+	ejected atomic.Bool // ejected 是否已被摘除
+
+	failures  int // failures 连续探测失败次数，只在探测协程中访问
+	successes int // successes 摘除后连续探测成功次数，只在探测协程中访问
+}
+
+// target 返回实际执行操作的连接池
+func (r *replicaConn) target() *sql.DB {
+	if r.ejected.Load() {
+		return r.primary
+	}
+	return r.replica
+}
+
+// PrepareContext 实现 gorm.ConnPool
+func (r *replicaConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.target().PrepareContext(ctx, query)
+}
+
+// ExecContext 实现 gorm.ConnPool
+func (r *replicaConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.target().ExecContext(ctx, query, args...)
+}
+
+// QueryContext 实现 gorm.ConnPool
+func (r *replicaConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.target().QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext 实现 gorm.ConnPool
+func (r *replicaConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.target().QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx 实现 gorm.TxBeginner
+func (r *replicaConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return r.target().BeginTx(ctx, opts)
+}
+
+// GetDBConn 实现 gorm.GetDBConnector，返回从库连接池
+func (r *replicaConn) GetDBConn() (*sql.DB, error) {
+	return r.replica, nil
+}
+
+// replicaDialector 打开从库后把连接池包装为 replicaConn 并交给探测器管理
+type replicaDialector struct {
+	gorm.Dialector
+	prober *replicaProber // prober 从库探测器
+	index  int            // index 从库在 DBConfig.Replicas 中的下标
+}
+
+// Initialize 实现 gorm.Dialector
+func (d replicaDialector) Initialize(db *gorm.DB) error {
+	if err := d.Dialector.Initialize(db); err != nil {
+		return err
+	}
+	sqlDB, ok := db.ConnPool.(*sql.DB)
+	if !ok {
+		return fmt.Errorf("%w: replica probing requires a *sql.DB connection pool, got %T", errInvalidReplica, db.ConnPool)
+	}
+
+	conn := &replicaConn{replica: sqlDB, primary: d.prober.primary}
+	d.prober.replicas[d.index] = conn
+	db.ConnPool = conn
+	return nil
+}
+
+// replicaProber 在后台定期探测从库，连续失败时摘除、恢复后重新启用。
+// 作为 gorm 插件注册到主库的 *gorm.DB 上，以便关闭连接时能够停止探测。
+type replicaProber struct {
+	group, name    string
+	interval       time.Duration
+	timeout        time.Duration
+	ejectAfter     int
+	reinstateAfter int
+	onEvent        func(ReplicaEvent)
+
+	primary  *sql.DB
+	replicas []*replicaConn
+
+	running  bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// replicaProberName 从库探测器在 gorm 中的插件名
+const replicaProberName = "mgorm:replica_prober"
+
+// Name 实现 gorm.Plugin
+func (p *replicaProber) Name() string {
+	return replicaProberName
+}
+
+// Initialize 实现 gorm.Plugin
+func (p *replicaProber) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// policy 包装 base，只在未被摘除的从库中选择；全部被摘除时返回的 replicaConn 会转发到主库
+func (p *replicaProber) policy(base dbresolver.Policy) dbresolver.Policy {
+	return dbresolver.PolicyFunc(func(connPools []gorm.ConnPool) gorm.ConnPool {
+		healthy := make([]gorm.ConnPool, 0, len(connPools))
+		for _, connPool := range connPools {
+			if r, ok := connPool.(*replicaConn); !ok || !r.ejected.Load() {
+				healthy = append(healthy, connPool)
+			}
+		}
+
+		switch len(healthy) {
+		case 0:
+			return connPools[0]
+		case 1:
+			return healthy[0]
+		default:
+			return base.Resolve(healthy)
+		}
+	})
+}
+
+// start 启动后台探测
+func (p *replicaProber) start() {
+	p.running = true
+	go p.run()
+}
+
+// run 每隔 interval 探测一次所有从库，直到 stop 被关闭
+func (p *replicaProber) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.probe()
+		}
+	}
+}
+
+// probe 并发探测所有从库
+func (p *replicaProber) probe() {
+	var wg sync.WaitGroup
+	for i, r := range p.replicas {
+		wg.Add(1)
+		go func(i int, r *replicaConn) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+			defer cancel()
+			p.record(i, r, r.replica.PingContext(ctx))
+		}(i, r)
+	}
+	wg.Wait()
+}
+
+// record 记录一次探测结果，达到阈值时摘除或恢复从库
+func (p *replicaProber) record(i int, r *replicaConn, err error) {
+	if err != nil {
+		r.successes = 0
+		r.failures++
+		if !r.ejected.Load() && r.failures >= p.ejectAfter {
+			r.ejected.Store(true)
+			p.emit(ReplicaEvent{Type: ReplicaEjected, Replica: i, Err: err})
+		}
+		return
+	}
+
+	r.failures = 0
+	if !r.ejected.Load() {
+		return
+	}
+	r.successes++
+	if r.successes >= p.reinstateAfter {
+		r.successes = 0
+		r.ejected.Store(false)
+		p.emit(ReplicaEvent{Type: ReplicaReinstated, Replica: i})
+	}
+}
+
+// emit 补全组名与连接名后通知事件
+func (p *replicaProber) emit(event ReplicaEvent) {
+	if p.onEvent == nil {
+		return
+	}
+	event.Group, event.Name = p.group, p.name
+	p.onEvent(event)
+}
+
+// close 停止后台探测并等待探测协程退出
+func (p *replicaProber) close() {
+	p.stopOnce.Do(func() { close(p.stop) })
+	if p.running {
+		<-p.done
+	}
+}
+
+// replicaProberOf 返回注册在 db 上的从库探测器
+func replicaProberOf(db *gorm.DB) (*replicaProber, bool) {
+	plugin, ok := db.Config.Plugins[replicaProberName]
+	if !ok {
+		return nil, false
+	}
+	p, ok := plugin.(*replicaProber)
+	return p, ok
+}
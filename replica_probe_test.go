@@ -0,0 +1,169 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// TestReplicaProber_Record 测试连续失败摘除、连续成功恢复的阈值
+func TestReplicaProber_Record(t *testing.T) {
+	var events []ReplicaEvent
+	config := DBConfig{
+		Replicas:              []ReplicaConfig{{}},
+		ReplicaProbeInterval:  time.Second,
+		ReplicaEjectAfter:     2,
+		ReplicaReinstateAfter: 2,
+		OnReplicaEvent:        func(e ReplicaEvent) { events = append(events, e) },
+		groupName:             "main",
+		connName:              "db",
+	}
+	p := config.newReplicaProber(nil)
+	r := &replicaConn{}
+	probeErr := errors.New("probe failed")
+
+	steps := []struct {
+		err     error
+		ejected bool
+		events  int
+	}{
+		{probeErr, false, 0},
+		{nil, false, 0}, // 成功会重置连续失败次数
+		{probeErr, false, 0},
+		{probeErr, true, 1},
+		{probeErr, true, 1},
+		{nil, true, 1},
+		{nil, false, 2},
+	}
+	for i, step := range steps {
+		p.record(0, r, step.err)
+		if r.ejected.Load() != step.ejected || len(events) != step.events {
+			t.Fatalf("第 %d 步: ejected=%v events=%d, 期望 ejected=%v events=%d",
+				i+1, r.ejected.Load(), len(events), step.ejected, step.events)
+		}
+	}
+
+	if e := events[0]; e.Type != ReplicaEjected || e.Group != "main" || e.Name != "db" || !errors.Is(e.Err, probeErr) {
+		t.Errorf("摘除事件 = %+v", e)
+	}
+	if e := events[1]; e.Type != ReplicaReinstated || e.Err != nil {
+		t.Errorf("恢复事件 = %+v", e)
+	}
+}
+
+// TestGroup_ReplicaEjection 测试从库不可用时读操作转到主库，恢复后重新使用从库，关闭后停止探测
+func TestGroup_ReplicaEjection(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+	seedSQLite(t, primary, "from_primary")
+	seedSQLite(t, replica, "from_replica")
+
+	events := make(chan ReplicaEvent, 10)
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "db", DBConfig{
+		DriverType:           "sqlite",
+		DBName:               primary,
+		MaxOpenConns:         1,
+		Replicas:             []ReplicaConfig{{DBName: replica}},
+		ReplicaProbeInterval: 10 * time.Millisecond,
+		ReplicaProbeTimeout:  20 * time.Millisecond,
+		ReplicaEjectAfter:    2,
+		OnReplicaEvent:       func(e ReplicaEvent) { events <- e },
+	})
+	db := group.MustGet(ctx, "db")
+
+	readName := func() string {
+		var user replicaUser
+		if err := db.First(&user).Error; err != nil {
+			t.Fatalf("查询失败: %v", err)
+		}
+		return user.Name
+	}
+	waitEvent := func(want ReplicaEventType) {
+		select {
+		case e := <-events:
+			if e.Type != want || e.Name != "db" || e.Replica != 0 {
+				t.Fatalf("事件 = %+v, 期望 %s", e, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("等待 %s 事件超时", want)
+		}
+	}
+
+	if name := readName(); name != "from_replica" {
+		t.Fatalf("读操作应使用从库，实际读到 %q", name)
+	}
+
+	// 占用从库唯一的连接，使探测超时
+	var replicaDB *sql.DB
+	eachSQLDB(db, func(sqlDB *sql.DB) error {
+		replicaDB = sqlDB
+		return nil
+	})
+	conn, err := replicaDB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("获取从库连接失败: %v", err)
+	}
+
+	waitEvent(ReplicaEjected)
+	if name := readName(); name != "from_primary" {
+		t.Errorf("从库被摘除后读操作应使用主库，实际读到 %q", name)
+	}
+
+	conn.Close()
+	waitEvent(ReplicaReinstated)
+	if name := readName(); name != "from_replica" {
+		t.Errorf("从库恢复后读操作应使用从库，实际读到 %q", name)
+	}
+
+	prober, ok := replicaProberOf(db)
+	if !ok {
+		t.Fatal("应注册从库探测器")
+	}
+	group.Close(ctx)
+	select {
+	case <-prober.done:
+	default:
+		t.Error("Group.Close 之后探测协程应已退出")
+	}
+}
+
+// TestDBConfig_Validate_ReplicaProbe 测试从库探测参数校验
+func TestDBConfig_Validate_ReplicaProbe(t *testing.T) {
+	config := DBConfig{
+		DriverType:           "sqlite",
+		DBName:               ":memory:",
+		Replicas:             []ReplicaConfig{{DBName: "replica.db"}},
+		ReplicaProbeInterval: -time.Second,
+	}
+	if err := config.Validate(); !IsErrInvalidReplica(err) {
+		t.Errorf("负数探测间隔应返回 InvalidReplica 错误，实际为: %v", err)
+	}
+}
+
+// TestReplicaProber_Policy 测试选择策略跳过被摘除的从库
+func TestReplicaProber_Policy(t *testing.T) {
+	p := (&DBConfig{}).newReplicaProber(nil)
+	a, b := &replicaConn{}, &replicaConn{}
+	policy := p.policy(dbresolver.RandomPolicy{})
+
+	a.ejected.Store(true)
+	for i := 0; i < 10; i++ {
+		if got := policy.Resolve([]gorm.ConnPool{a, b}); got != b {
+			t.Fatal("应跳过被摘除的从库")
+		}
+	}
+
+	b.ejected.Store(true)
+	if got := policy.Resolve([]gorm.ConnPool{a, b}); got != a {
+		t.Error("全部被摘除时应返回第一个从库（转发到主库）")
+	}
+}
@@ -0,0 +1,199 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// replicaUser 读写分离测试使用的模型
+type replicaUser struct {
+	ID   uint
+	Name string
+}
+
+// seedSQLite 在 path 对应的 SQLite 数据库中创建表并写入 names
+func seedSQLite(t *testing.T, path string, names ...string) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开 %s 失败: %v", path, err)
+	}
+	defer closer(context.Background(), db)
+
+	if err := db.AutoMigrate(&replicaUser{}); err != nil {
+		t.Fatalf("迁移 %s 失败: %v", path, err)
+	}
+	for _, name := range names {
+		db.Create(&replicaUser{Name: name})
+	}
+}
+
+// TestGroup_Replicas 测试读操作使用从库、写操作与 UsePrimary 使用主库
+func TestGroup_Replicas(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+	seedSQLite(t, primary, "from_primary")
+	seedSQLite(t, replica, "from_replica")
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "db", DBConfig{
+		DriverType: "sqlite",
+		DBName:     primary,
+		Replicas:   []ReplicaConfig{{DBName: replica}},
+	})
+	db := group.MustGet(ctx, "db")
+
+	var user replicaUser
+	db.First(&user)
+	if user.Name != "from_replica" {
+		t.Errorf("读操作应使用从库，实际读到 %q", user.Name)
+	}
+
+	if err := db.Create(&replicaUser{Name: "written"}).Error; err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	var count int64
+	UsePrimary(db).Model(&replicaUser{}).Where("name = ?", "written").Count(&count)
+	if count != 1 {
+		t.Errorf("写操作应使用主库，UsePrimary 读到 %d 条", count)
+	}
+	db.Model(&replicaUser{}).Where("name = ?", "written").Count(&count)
+	if count != 0 {
+		t.Errorf("从库不应包含写入的数据，实际读到 %d 条", count)
+	}
+
+	// 关闭后主库与从库连接都应关闭
+	var sqlDBs []*sql.DB
+	eachSQLDB(db, func(sqlDB *sql.DB) error {
+		sqlDBs = append(sqlDBs, sqlDB)
+		return nil
+	})
+	if len(sqlDBs) != 2 {
+		t.Fatalf("期望 2 个连接池，实际 %d 个", len(sqlDBs))
+	}
+	group.Close(ctx)
+	for i, sqlDB := range sqlDBs {
+		if err := sqlDB.Ping(); err == nil {
+			t.Errorf("关闭后连接池 %d 仍可用", i)
+		}
+	}
+}
+
+// TestGroup_ReplicaUnavailable 测试从库不可用时 Get 返回错误
+func TestGroup_ReplicaUnavailable(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "db", DBConfig{
+		DriverType: "sqlite",
+		DBName:     ":memory:",
+		Replicas:   []ReplicaConfig{{DBName: filepath.Join(t.TempDir(), "not_exists", "replica.db")}},
+	})
+
+	if _, err := group.Get(ctx, "db"); err == nil {
+		t.Error("从库不可用时 Get 应返回错误")
+	}
+}
+
+// TestDBConfig_ReplicaPolicy 测试从库选择策略
+func TestDBConfig_ReplicaPolicy(t *testing.T) {
+	pools := []gorm.ConnPool{&sql.DB{}, &sql.DB{}}
+
+	for _, name := range []string{"", ReplicaPolicyRandom, ReplicaPolicyRoundRobin, ReplicaPolicyLeastConn} {
+		if _, err := (&DBConfig{ReplicaPolicy: name}).replicaPolicy(); err != nil {
+			t.Errorf("策略 %q 应有效: %v", name, err)
+		}
+	}
+
+	roundRobin, _ := (&DBConfig{ReplicaPolicy: ReplicaPolicyRoundRobin}).replicaPolicy()
+	first, second := roundRobin.Resolve(pools), roundRobin.Resolve(pools)
+	if first == second || roundRobin.Resolve(pools) != first {
+		t.Error("round_robin 应轮流选择从库")
+	}
+
+	_, err := (&DBConfig{ReplicaPolicy: "weighted"}).replicaPolicy()
+	if !IsErrInvalidReplica(err) {
+		t.Errorf("未知策略应返回 InvalidReplica 错误，实际为: %v", err)
+	}
+}
+
+// TestLeastConn 测试 least_conn 选择使用中连接数最少的从库
+func TestLeastConn(t *testing.T) {
+	busy, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open() 失败: %v", err)
+	}
+	defer busy.Close()
+	idle, _ := sql.Open("sqlite3", ":memory:")
+	defer idle.Close()
+
+	conn, err := busy.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("获取连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	if got := leastConn([]gorm.ConnPool{busy, idle}); got != idle {
+		t.Error("least_conn 应选择没有使用中连接的从库")
+	}
+}
+
+// TestDBConfig_Validate_Replicas 测试从库配置校验
+func TestDBConfig_Validate_Replicas(t *testing.T) {
+	valid := DBConfig{DriverType: "sqlite", DBName: ":memory:", Replicas: []ReplicaConfig{{DBName: "replica.db"}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("有效配置返回错误: %v", err)
+	}
+
+	// 显式 Dialector 的从库通过 Dialector.Name() 确定驱动
+	withDialector := DBConfig{Dialector: sqlite.Open(":memory:"), Replicas: []ReplicaConfig{{DBName: "replica.db"}}}
+	if err := withDialector.Validate(); err != nil {
+		t.Errorf("显式 Dialector 的配置返回错误: %v", err)
+	}
+
+	missing := DBConfig{DriverType: "mysql", DSN: "root@tcp(127.0.0.1:3306)/app", Replicas: []ReplicaConfig{{Port: 3307}}}
+	err := missing.Validate()
+	if !IsErrNoDSN(err) || !strings.Contains(err.Error(), "replicas[0]") {
+		t.Errorf("从库缺少 host 时应返回带索引的 NoDSN 错误，实际为: %v", err)
+	}
+}
+
+// TestDecodeManagerConfig_Replicas 测试从库配置的解码
+func TestDecodeManagerConfig_Replicas(t *testing.T) {
+	yml := `
+main:
+  db1:
+    driver_type: "mysql"
+    host: "primary.local"
+    port: 3306
+    db_name: "app"
+    replica_policy: "round_robin"
+    replicas:
+      - host: "replica1.local"
+      - dsn: "root@tcp(replica2.local:3306)/app"
+`
+	cfgs, err := DecodeManagerConfig(strings.NewReader(yml), FormatYAML)
+	if err != nil {
+		t.Fatalf("DecodeManagerConfig() 失败: %v", err)
+	}
+	cfg := cfgs["main"]["db1"]
+	if cfg.ReplicaPolicy != ReplicaPolicyRoundRobin || len(cfg.Replicas) != 2 {
+		t.Fatalf("解码结果 = %+v", cfg)
+	}
+
+	replicas := cfg.replicaConfigs()
+	if dsn := replicas[0].AutoDsn(); !strings.Contains(dsn, "replica1.local:3306)/app") {
+		t.Errorf("从库应继承主库的端口与数据库名，DSN = %q", dsn)
+	}
+	if replicas[1].DSN != "root@tcp(replica2.local:3306)/app" {
+		t.Errorf("从库 DSN = %q", replicas[1].DSN)
+	}
+}
@@ -0,0 +1,120 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 重试等待时间的默认值
+const (
+	// DefaultRetryInitialBackoff 未设置 RetryInitialBackoff 时首次重试前的等待时间
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+	// DefaultRetryMaxBackoff 未设置 RetryMaxBackoff 时单次等待时间的上限
+	DefaultRetryMaxBackoff = 5 * time.Second
+)
+
+// IsRetryableError 是默认的可重试错误判断，驱动未提供 DriverSpec.IsRetryable 时使用。
+//
+// 网络错误（如连接被拒绝、超时）、driver.ErrBadConn 以及连接被意外关闭（io.EOF）视为可重试；
+// context 取消或超时以及其他错误（如认证失败、数据库文件无法打开）视为不可重试。
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// validateRetry 检查重试参数
+func (c *DBConfig) validateRetry() error {
+	switch {
+	case c.RetryMaxAttempts < 0:
+		return fmt.Errorf("%w: retry_max_attempts (%d) must not be negative", errInvalidRetry, c.RetryMaxAttempts)
+	case c.RetryInitialBackoff < 0 || c.RetryMaxBackoff < 0:
+		return fmt.Errorf("%w: retry backoff must not be negative", errInvalidRetry)
+	case c.RetryInitialBackoff > 0 && c.RetryMaxBackoff > 0 && c.RetryInitialBackoff > c.RetryMaxBackoff:
+		return fmt.Errorf("%w: retry_initial_backoff (%s) must not exceed retry_max_backoff (%s)",
+			errInvalidRetry, c.RetryInitialBackoff, c.RetryMaxBackoff)
+	case c.RetryJitter < 0 || c.RetryJitter > 1:
+		return fmt.Errorf("%w: retry_jitter (%g) must be between 0 and 1", errInvalidRetry, c.RetryJitter)
+	}
+	return nil
+}
+
+// retryClassifier 返回判断错误是否可重试的函数。
+// 依次使用 DriverType、Dialector.Name() 查找驱动的 IsRetryable，都未提供时使用 IsRetryableError。
+func (c *DBConfig) retryClassifier(dialector gorm.Dialector) func(error) bool {
+	for _, name := range []string{c.DriverType, dialector.Name()} {
+		if spec, ok := lookupDriver(name); ok && spec.IsRetryable != nil {
+			return spec.IsRetryable
+		}
+	}
+	return IsRetryableError
+}
+
+// retryBackoff 返回第 attempt 次尝试失败后的等待时间：
+// 从 RetryInitialBackoff 开始每次翻倍，不超过 RetryMaxBackoff，再按 RetryJitter 随机缩短。
+func (c *DBConfig) retryBackoff(attempt int) time.Duration {
+	backoff, maxBackoff := c.RetryInitialBackoff, c.RetryMaxBackoff
+	if backoff == 0 {
+		backoff = DefaultRetryInitialBackoff
+	}
+	if maxBackoff == 0 {
+		maxBackoff = max(DefaultRetryMaxBackoff, backoff)
+	}
+
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxBackoff)
+
+	if c.RetryJitter > 0 {
+		backoff -= time.Duration(rand.Float64() * c.RetryJitter * float64(backoff))
+	}
+	return backoff
+}
+
+// retryOpen 调用 open 打开连接，失败且错误可重试时按退避策略重试，
+// 直到成功、错误不可重试、达到 RetryMaxAttempts 或 ctx 结束（包括下次尝试前就会超过截止时间）。
+//
+// 未配置重试（RetryMaxAttempts <= 1）时直接返回 open 的结果；
+// 配置了重试时，最终错误会包含已尝试的次数并包装最后一次的错误。
+func (c *DBConfig) retryOpen(ctx context.Context, isRetryable func(error) bool, open func(context.Context) (*gorm.DB, error)) (*gorm.DB, error) {
+	if c.RetryMaxAttempts <= 1 {
+		return open(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		db, err := open(ctx)
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= c.RetryMaxAttempts || !isRetryable(err) {
+			return nil, fmt.Errorf("mgorm: open database failed after %d attempt(s): %w", attempt, err)
+		}
+
+		wait := c.retryBackoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, fmt.Errorf("mgorm: open database failed after %d attempt(s), context deadline too close to retry: %w", attempt, err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("mgorm: open database failed after %d attempt(s): %w", attempt, errors.Join(err, ctx.Err()))
+		case <-timer.C:
+		}
+	}
+}
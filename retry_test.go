@@ -0,0 +1,343 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qq1060656096/bizutil/registry"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// registerFlakyDriver 注册一个使用 SQLite 且所有错误都可重试的驱动，
+// onRetry 在每次判断是否重试时调用，返回判断次数计数器
+func registerFlakyDriver(t *testing.T, onRetry func(n int32)) *int32 {
+	t.Helper()
+	var calls int32
+	RegisterDriver("flaky_sqlite", DriverSpec{
+		Dialector: sqlite.Open,
+		DSN:       SQLiteDSN,
+		IsRetryable: func(err error) bool {
+			n := atomic.AddInt32(&calls, 1)
+			if onRetry != nil {
+				onRetry(n)
+			}
+			return true
+		},
+	})
+	t.Cleanup(func() { unregisterDriver("flaky_sqlite") })
+	return &calls
+}
+
+// TestOpener_RetrySucceeds 测试数据库稍后可用时重试成功
+func TestOpener_RetrySucceeds(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "later")
+	calls := registerFlakyDriver(t, func(n int32) {
+		// 第二次失败后数据库才“启动”
+		if n == 2 {
+			os.MkdirAll(dir, 0o755)
+		}
+	})
+
+	db, err := opener(context.Background(), DBConfig{
+		DriverType:          "flaky_sqlite",
+		DBName:              filepath.Join(dir, "app.db"),
+		RetryMaxAttempts:    5,
+		RetryInitialBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("opener() 失败: %v", err)
+	}
+	defer closer(context.Background(), db)
+
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Errorf("重试判断次数 = %d, 期望 2", n)
+	}
+}
+
+// TestOpener_RetryExhausted 测试重试次数用尽后错误包含尝试次数
+func TestOpener_RetryExhausted(t *testing.T) {
+	calls := registerFlakyDriver(t, nil)
+
+	_, err := opener(context.Background(), DBConfig{
+		DriverType:          "flaky_sqlite",
+		DBName:              filepath.Join(t.TempDir(), "not_exists", "app.db"),
+		RetryMaxAttempts:    3,
+		RetryInitialBackoff: time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("opener() 应返回错误")
+	}
+	if !strings.Contains(err.Error(), "after 3 attempt(s)") {
+		t.Errorf("错误信息应包含尝试次数，实际为: %v", err)
+	}
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Errorf("重试判断次数 = %d, 期望 2", n)
+	}
+}
+
+// TestOpener_RetryNotRetryable 测试不可重试的错误不会重试
+func TestOpener_RetryNotRetryable(t *testing.T) {
+	// 内置 sqlite 驱动未提供 IsRetryable，文件无法打开的错误按 IsRetryableError 判断为不可重试
+	_, err := opener(context.Background(), DBConfig{
+		DriverType:          "sqlite",
+		DBName:              filepath.Join(t.TempDir(), "not_exists", "app.db"),
+		RetryMaxAttempts:    3,
+		RetryInitialBackoff: time.Hour,
+	})
+	if err == nil || !strings.Contains(err.Error(), "after 1 attempt(s)") {
+		t.Errorf("不可重试的错误应在第 1 次尝试后返回，实际为: %v", err)
+	}
+}
+
+// TestOpener_RetryDeadline 测试重试遵守 ctx 的截止时间
+func TestOpener_RetryDeadline(t *testing.T) {
+	registerFlakyDriver(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := opener(ctx, DBConfig{
+		DriverType:          "flaky_sqlite",
+		DBName:              filepath.Join(t.TempDir(), "not_exists", "app.db"),
+		RetryMaxAttempts:    10,
+		RetryInitialBackoff: 40 * time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("opener() 应返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("截止时间后应停止重试，实际耗时 %v", elapsed)
+	}
+	if !strings.Contains(err.Error(), "attempt(s)") {
+		t.Errorf("错误信息应包含尝试次数，实际为: %v", err)
+	}
+}
+
+// TestGroup_GetDuringRetry 测试一个连接重试等待期间，不阻塞其他连接的 Get 与 Register
+func TestGroup_GetDuringRetry(t *testing.T) {
+	ctx := context.Background()
+	retrying := make(chan struct{})
+	release := make(chan struct{})
+	registerFlakyDriver(t, func(n int32) {
+		if n == 1 {
+			close(retrying)
+			<-release
+		}
+	})
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "ok", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+	openedDB := group.MustGet(ctx, "ok")
+	group.Register(ctx, "slow", DBConfig{
+		DriverType:          "flaky_sqlite",
+		DBName:              filepath.Join(t.TempDir(), "missing", "app.db"),
+		RetryMaxAttempts:    2,
+		RetryInitialBackoff: time.Millisecond,
+	})
+
+	slowErr := make(chan error, 1)
+	go func() {
+		_, err := group.Get(ctx, "slow")
+		slowErr <- err
+	}()
+	<-retrying
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if db, err := group.Get(ctx, "ok"); err != nil || db != openedDB {
+			t.Errorf("Get(ok) = %v, %v", db, err)
+		}
+		group.Register(ctx, "other", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("重试期间 Get、Register 其他连接不应被阻塞")
+	}
+
+	close(release)
+	if err := <-slowErr; err == nil {
+		t.Error("Get(slow) 应返回错误")
+	}
+	<-done
+	if _, ok := OpenedDB(group, "slow"); ok {
+		t.Error("打开失败的连接不应被记录为已打开")
+	}
+}
+
+// TestGroup_GetUnregisterWhileOpening 测试锁外打开期间连接被注销时，打开的连接被关闭而不是被注册
+func TestGroup_GetUnregisterWhileOpening(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "later")
+	retrying := make(chan struct{})
+	release := make(chan struct{})
+	registerFlakyDriver(t, func(n int32) {
+		close(retrying)
+		<-release
+		os.MkdirAll(dir, 0o755)
+	})
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "db", DBConfig{
+		DriverType:          "flaky_sqlite",
+		DBName:              filepath.Join(dir, "app.db"),
+		RetryMaxAttempts:    2,
+		RetryInitialBackoff: time.Millisecond,
+	})
+
+	getErr := make(chan error, 1)
+	go func() {
+		_, err := group.Get(ctx, "db")
+		getErr <- err
+	}()
+	<-retrying
+	if err := group.Unregister(ctx, "db"); err != nil {
+		t.Fatalf("Unregister() 失败: %v", err)
+	}
+	close(release)
+
+	if err := <-getErr; !errors.Is(err, registry.ErrResourceNotFound) {
+		t.Errorf("错误应为 ErrResourceNotFound，实际为: %v", err)
+	}
+	g, _ := mgormGroup(group)
+	if len(g.pools.preopened) != 0 {
+		t.Error("注销后打开的连接应被关闭")
+	}
+}
+
+// TestGroup_GetConcurrent 测试并发 Get 同一连接时只打开一次
+func TestGroup_GetConcurrent(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "db", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+
+	dbs := make([]*gorm.DB, 8)
+	var wg sync.WaitGroup
+	for i := range dbs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dbs[i] = group.MustGet(ctx, "db")
+		}(i)
+	}
+	wg.Wait()
+	for _, db := range dbs {
+		if db != dbs[0] {
+			t.Fatal("并发 Get 应返回同一连接")
+		}
+	}
+	g, _ := mgormGroup(group)
+	if len(g.pools.preopened) != 0 || len(g.pools.opening) != 0 {
+		t.Error("不应遗留锁外打开的连接")
+	}
+}
+
+// TestDBConfig_RetryBackoff 测试指数退避、上限与抖动
+func TestDBConfig_RetryBackoff(t *testing.T) {
+	config := DBConfig{RetryInitialBackoff: 100 * time.Millisecond, RetryMaxBackoff: time.Second}
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, want := range expected {
+		if got := config.retryBackoff(i + 1); got != want*time.Millisecond {
+			t.Errorf("retryBackoff(%d) = %v, 期望 %v", i+1, got, want*time.Millisecond)
+		}
+	}
+
+	if got := (&DBConfig{}).retryBackoff(1); got != DefaultRetryInitialBackoff {
+		t.Errorf("默认首次等待时间 = %v, 期望 %v", got, DefaultRetryInitialBackoff)
+	}
+	if got := (&DBConfig{}).retryBackoff(100); got != DefaultRetryMaxBackoff {
+		t.Errorf("默认等待时间上限 = %v, 期望 %v", got, DefaultRetryMaxBackoff)
+	}
+
+	config.RetryJitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := config.retryBackoff(1); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("抖动后的等待时间 %v 超出 [50ms, 100ms]", got)
+		}
+	}
+}
+
+// TestDBConfig_Validate_Retry 测试重试参数校验
+func TestDBConfig_Validate_Retry(t *testing.T) {
+	base := DBConfig{DriverType: "sqlite", DBName: ":memory:"}
+	tests := []struct {
+		name   string
+		modify func(c *DBConfig)
+		valid  bool
+	}{
+		{"未设置", func(c *DBConfig) {}, true},
+		{"有效", func(c *DBConfig) {
+			c.RetryMaxAttempts, c.RetryInitialBackoff, c.RetryMaxBackoff, c.RetryJitter = 5, time.Second, time.Minute, 0.2
+		}, true},
+		{"负数次数", func(c *DBConfig) { c.RetryMaxAttempts = -1 }, false},
+		{"负数等待", func(c *DBConfig) { c.RetryInitialBackoff = -time.Second }, false},
+		{"首次等待超过上限", func(c *DBConfig) { c.RetryInitialBackoff, c.RetryMaxBackoff = time.Minute, time.Second }, false},
+		{"抖动超过 1", func(c *DBConfig) { c.RetryJitter = 1.5 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := base
+			tt.modify(&config)
+			err := config.Validate()
+			if tt.valid != (err == nil) {
+				t.Fatalf("Validate() 错误 = %v, 期望有效 %v", err, tt.valid)
+			}
+			if !tt.valid && !IsErrInvalidRetry(err) {
+				t.Errorf("错误应为 InvalidRetry 类型，实际为: %v", err)
+			}
+		})
+	}
+}
+
+// TestDBConfig_RetryDecode 测试重试参数的 JSON 解码
+func TestDBConfig_RetryDecode(t *testing.T) {
+	var config DBConfig
+	data := `{"retry_max_attempts": 5, "retry_initial_backoff": "200ms", "retry_max_backoff": "10s", "retry_jitter": 0.3}`
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("json.Unmarshal() 失败: %v", err)
+	}
+	if config.RetryMaxAttempts != 5 || config.RetryInitialBackoff != 200*time.Millisecond ||
+		config.RetryMaxBackoff != 10*time.Second || config.RetryJitter != 0.3 {
+		t.Errorf("解码结果 = %+v", config)
+	}
+}
+
+// TestIsRetryableError 测试默认的可重试错误判断
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"context canceled", context.Canceled, false},
+		{"deadline exceeded", fmt.Errorf("ping: %w", context.DeadlineExceeded), false},
+		{"bad conn", driver.ErrBadConn, true},
+		{"connection refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"other", errors.New("access denied"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableError(%v) = %v, 期望 %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
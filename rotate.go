@@ -0,0 +1,159 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultRotateDrainTimeout 是 RotateOptions.DrainTimeout 为 0 时旧连接池的最长排空时间
+const DefaultRotateDrainTimeout = 30 * time.Second
+
+// ErrRotateUnsupported 当 Group 不是由 New、NewManager 创建时 Rotate 返回此错误
+var ErrRotateUnsupported = errors.New("mgorm: rotate requires a Group created by mgorm")
+
+// drainPollInterval 排空旧连接池时检查使用中连接数的间隔
+var drainPollInterval = 100 * time.Millisecond
+
+// RotateOptions Rotate 的选项
+type RotateOptions struct {
+	// DrainTimeout 旧连接池的最长排空时间，为 0 时使用 DefaultRotateDrainTimeout。
+	// 旧连接池在没有使用中的连接后关闭，超过该时间仍有使用中的连接时也会关闭
+	DrainTimeout time.Duration
+	// Update 重新注册前修改配置（可选），如替换 Password；为 nil 时使用原配置，由密钥引用提供新凭据
+	Update func(cfg *DBConfig)
+}
+
+// Rotate 使用默认选项轮换连接的凭据，参见 RotateWithOptions。
+func Rotate(ctx context.Context, group Group, name string) error {
+	return RotateWithOptions(ctx, group, name, RotateOptions{})
+}
+
+// RotateWithOptions 以 name 当前的配置（及 Update 的修改）重新注册连接并打开新的连接池，
+// 新连接池重新解析 Password、DSN 中的密钥引用，因此轮换凭据不需要重启进程。
+//
+// 新配置先打开并 Ping 一次，失败时返回错误并保留原连接。
+// 之后对 Get 原子地替换注册，旧连接池不再由 Get 返回，在使用中的连接归还（如事务提交）后关闭，
+// 最多等待 DrainTimeout。调用方不应长期持有 Get 返回的 *gorm.DB，而应在每次使用时重新获取。
+//
+// 通过 RegisterToSchema 以 SharedPool 模式注册的连接不能轮换，需要轮换来源连接后重新注册。
+func RotateWithOptions(ctx context.Context, group Group, name string, opts RotateOptions) error {
+	if _, ok := mgormGroup(group); !ok {
+		return ErrRotateUnsupported
+	}
+
+	cfg, err := group.Config(ctx, name)
+	if err != nil {
+		return err
+	}
+	if cfg.sharedFrom != "" {
+		return fmt.Errorf("mgorm: rotate %s: connection shares the pool of another connection, rotate that connection instead", name)
+	}
+	if opts.Update != nil {
+		opts.Update(&cfg)
+	}
+
+	// 先验证新凭据可用，失败时不影响正在使用的连接
+	if err := probeConfig(ctx, cfg); err != nil {
+		return fmt.Errorf("mgorm: rotate %s: %w", name, err)
+	}
+	if err := swapConfig(ctx, group, name, cfg, opts.DrainTimeout); err != nil {
+		return fmt.Errorf("mgorm: rotate %s: %w", name, err)
+	}
+
+	_, err = group.Get(ctx, name)
+	return err
+}
+
+// probeConfig 使用 cfg 打开连接并 Ping 后立即关闭，用于在替换注册前验证配置能够连接
+func probeConfig(ctx context.Context, cfg DBConfig) error {
+	db, err := opener(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return closeSQLDBs(db)
+}
+
+// swapConfig 对 Get 原子地把 name 的注册替换为 cfg，不打开新连接；
+// 已打开的旧连接在排空后关闭，最多等待 drainTimeout（为 0 时使用 DefaultRotateDrainTimeout）
+func swapConfig(ctx context.Context, group Group, name string, cfg DBConfig, drainTimeout time.Duration) error {
+	g, ok := mgormGroup(group)
+	if !ok {
+		return ErrRotateUnsupported
+	}
+
+	// 直接使用底层的 *group：EvictingGroup 的 Unregister 会获取 evictMu，
+	// 而 EvictingGroup.Get 持有 evictMu 时会获取 swapMu，在此获取会形成死锁
+	g.pools.swapMu.Lock()
+	defer g.pools.swapMu.Unlock()
+	old := g.pools.drainOpened(g.name, name, drainTimeout)
+	if err := g.Unregister(ctx, name); err != nil {
+		g.pools.cancelDrain(old)
+		return err
+	}
+	_, err := g.Register(ctx, name, cfg)
+	return err
+}
+
+// unregisterDraining 注销 name，已打开的连接在排空后关闭，最多等待 drainTimeout
+func unregisterDraining(ctx context.Context, group Group, name string, drainTimeout time.Duration) error {
+	g, ok := mgormGroup(group)
+	if !ok {
+		return group.Unregister(ctx, name)
+	}
+
+	old := g.pools.drainOpened(g.name, name, drainTimeout)
+	if err := group.Unregister(ctx, name); err != nil {
+		g.pools.cancelDrain(old)
+		return err
+	}
+	return nil
+}
+
+// drainOpened 标记已打开的连接在注销时排空后关闭，而不是立即关闭，返回被标记的连接；连接未打开时返回 nil
+func (p *pools) drainOpened(groupName, name string, timeout time.Duration) *gorm.DB {
+	if timeout <= 0 {
+		timeout = DefaultRotateDrainTimeout
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	db, ok := p.dbs[poolKey{group: groupName, name: name}]
+	if !ok {
+		return nil
+	}
+	p.draining[db] = timeout
+	return db
+}
+
+// cancelDrain 取消 drainOpened 的标记，用于注销失败时
+func (p *pools) cancelDrain(db *gorm.DB) {
+	if db == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.draining, db)
+}
+
+// drainAndClose 等待 db 的主库与从库都没有使用中的连接后关闭 db，最多等待 timeout
+func drainAndClose(db *gorm.DB, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) && inUseConns(db) > 0 {
+		time.Sleep(drainPollInterval)
+	}
+	_ = closer(context.Background(), db)
+}
+
+// inUseConns 返回 db 的主库与从库中使用中的连接数
+func inUseConns(db *gorm.DB) int {
+	inUse := 0
+	_ = eachSQLDB(db, func(sqlDB *sql.DB) error {
+		inUse += sqlDB.Stats().InUse
+		return nil
+	})
+	return inUse
+}
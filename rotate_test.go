@@ -0,0 +1,125 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRotate 测试轮换后 Get 返回使用新凭据的连接，旧连接池在事务结束后关闭
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	registerSecretSQLiteDriver(t)
+	drainPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { drainPollInterval = 100 * time.Millisecond })
+	dir := t.TempDir()
+	t.Setenv("MGORM_TEST_SECRET", "v1")
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "app", DBConfig{DriverType: "secret_sqlite", Host: dir, DBName: "app", Password: "env:MGORM_TEST_SECRET"})
+	old := group.MustGet(ctx, "app")
+
+	// 进行中的事务持有旧连接池的连接
+	tx := old.Begin()
+	if tx.Error != nil {
+		t.Fatalf("Begin() 失败: %v", tx.Error)
+	}
+
+	t.Setenv("MGORM_TEST_SECRET", "v2")
+	if err := RotateWithOptions(ctx, group, "app", RotateOptions{DrainTimeout: 10 * time.Second}); err != nil {
+		t.Fatalf("Rotate() 失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app_v2.db")); err != nil {
+		t.Errorf("轮换后应使用新凭据打开: %v", err)
+	}
+	current := group.MustGet(ctx, "app")
+	if current == old {
+		t.Fatal("轮换后 Get 应返回新的连接")
+	}
+	if db, _ := OpenedDB(group, "app"); db != current {
+		t.Error("OpenedDB 应返回新的连接")
+	}
+
+	// 旧连接池在事务结束前保持可用
+	oldSQLDB, _ := old.DB()
+	if err := oldSQLDB.Ping(); err != nil {
+		t.Fatalf("排空期间旧连接池不应关闭: %v", err)
+	}
+	if err := tx.Exec("SELECT 1").Error; err != nil {
+		t.Fatalf("轮换后进行中的事务应可继续执行: %v", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		t.Fatalf("Commit() 失败: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for oldSQLDB.Ping() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("事务结束后旧连接池应被关闭")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRotate_Failed 测试新凭据无法打开时保留原连接
+func TestRotate_Failed(t *testing.T) {
+	ctx := context.Background()
+	registerSecretSQLiteDriver(t)
+	dir := t.TempDir()
+	t.Setenv("MGORM_TEST_SECRET", "v1")
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "app", DBConfig{DriverType: "secret_sqlite", Host: dir, DBName: "app", Password: "env:MGORM_TEST_SECRET"})
+	old := group.MustGet(ctx, "app")
+
+	err := RotateWithOptions(ctx, group, "app", RotateOptions{Update: func(cfg *DBConfig) {
+		cfg.Password = "env:MGORM_TEST_SECRET_MISSING"
+	}})
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("应返回 ErrSecretNotFound，实际为: %v", err)
+	}
+	if group.MustGet(ctx, "app") != old {
+		t.Error("轮换失败时应保留原连接")
+	}
+	if err := old.Exec("SELECT 1").Error; err != nil {
+		t.Errorf("原连接应可用: %v", err)
+	}
+	if cfg := group.MustConfig(ctx, "app"); cfg.Password != "env:MGORM_TEST_SECRET" {
+		t.Errorf("轮换失败时不应修改配置，实际为 %q", cfg.Password)
+	}
+}
+
+// TestRotate_Update 测试通过 Update 替换配置，以及轮换尚未打开的连接
+func TestRotate_Update(t *testing.T) {
+	ctx := context.Background()
+	registerSecretSQLiteDriver(t)
+	dir := t.TempDir()
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "app", DBConfig{DriverType: "secret_sqlite", Host: dir, DBName: "app", Password: "old"})
+
+	err := Rotate(ctx, group, "app")
+	if err != nil {
+		t.Fatalf("Rotate() 失败: %v", err)
+	}
+	err = RotateWithOptions(ctx, group, "app", RotateOptions{Update: func(cfg *DBConfig) { cfg.Password = "new" }})
+	if err != nil {
+		t.Fatalf("Rotate() 失败: %v", err)
+	}
+	if cfg := group.MustConfig(ctx, "app"); cfg.Password != "new" {
+		t.Errorf("Password = %q, 期望 new", cfg.Password)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app_new.db")); err != nil {
+		t.Errorf("应使用新配置打开: %v", err)
+	}
+
+	if err := Rotate(ctx, group, "missing"); err == nil {
+		t.Error("连接不存在时应返回错误")
+	}
+}
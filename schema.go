@@ -0,0 +1,270 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+// sharedPoolName 是共享连接池标记在 gorm 中注册的插件名
+const sharedPoolName = "mgorm:shared_pool"
+
+// schemaNamePattern 合法的 schema 名，需要同时能写入 DSN 与作为表名前缀，因此不支持需要引号的名称
+var schemaNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// SchemaConfig schema 隔离配置，参见 RegisterToSchema
+type SchemaConfig struct {
+	// Schema schema 名：独立连接池时写入 DSN 的 search_path（PostgreSQL），共享连接池时作为表名的限定前缀
+	Schema string
+	// TablePrefix 表名前缀（可选），用于 MySQL 等没有 schema 的数据库，如 "tenant_a_"；设置时替换来源配置中的表名前缀
+	TablePrefix string
+	// SharedPool 与来源连接共用连接池，不为每个 schema 打开新的连接池
+	SharedPool bool
+}
+
+// RegisterToSchema 使用当前 Group 中已有名称 fromName 的配置，
+// 将其注册为新的名称 toName，与 fromName 使用同一个物理数据库，通过 schema 或表名前缀隔离。
+// 返回值 isNew 表示 toName 是否为新注册。
+//
+// 默认为 toName 打开独立的连接池：Schema 通过驱动的 DriverSpec.SetSearchPath 写入 DSN 的 search_path
+// （包括显式设置的 DSN），驱动不支持时返回错误；TablePrefix 通过 GORM 的命名策略生效。
+//
+// SharedPool 为 true 时 toName 与 fromName 共用连接池，Schema 与 TablePrefix 拼接为表名前缀（如 "tenant_a.user"）：
+//   - 按名称引用 fromName，toName 每次使用时通过 Get 获取 fromName 当前的连接池，
+//     因此 fromName 被轮换、淘汰后 toName 自动改用新的连接池；fromName 被注销后 toName 的查询返回错误
+//   - fromName 本身是 SharedPool 连接时，toName 共用同一个来源连接的连接池
+//   - 前缀只作用于 GORM 根据模型生成的表名，Raw、Exec 中的表名需要自行限定
+//   - 不使用 fromName 的从库，读写都使用主库
+//   - 连接池随 fromName 关闭，Unregister toName 不会关闭连接池
+//   - 连接池统计与健康检查中 toName 与 fromName 报告同一个连接池
+//   - group 必须由 mgorm 创建（可以被 EvictingGroup 等包装），RegisterToDB 复制 toName 时新连接使用独立的连接池
+func RegisterToSchema(ctx context.Context, group Group, fromName, toName string, sc SchemaConfig) (isNew bool, err error) {
+	if sc.Schema == "" && sc.TablePrefix == "" {
+		return false, errors.New("mgorm: SchemaConfig requires Schema or TablePrefix")
+	}
+	if sc.Schema != "" && !schemaNamePattern.MatchString(sc.Schema) {
+		return false, fmt.Errorf("mgorm: invalid schema name %q", sc.Schema)
+	}
+
+	cfg, err := group.Config(ctx, fromName)
+	if err != nil {
+		return false, err
+	}
+
+	cfg.Name = toName
+	if sc.TablePrefix != "" {
+		cfg.TablePrefix = sc.TablePrefix
+	}
+
+	if sc.SharedPool {
+		if sc.Schema != "" {
+			cfg.TablePrefix = sc.Schema + "." + cfg.TablePrefix
+		}
+		if _, ok := mgormGroup(group); !ok {
+			return false, errors.New("mgorm: SharedPool requires a Group created by mgorm")
+		}
+		cfg.Replicas = nil
+		if cfg.sharedFrom == "" {
+			cfg.sharedFrom = fromName
+		}
+		return group.Register(ctx, toName, cfg)
+	}
+
+	if sc.Schema != "" {
+		explicit := cfg.explicitDSN()
+		cfg.SearchPath = sc.Schema
+		if !explicit {
+			cfg.DSN = ""
+		}
+		dsn, err := cfg.setSearchPathInDSN(cfg.AutoDsn())
+		if err != nil {
+			return false, fmt.Errorf("%w; use TablePrefix or SharedPool instead", err)
+		}
+		dialector, err := CreateDialector(cfg.DriverType, dsn)
+		if err != nil {
+			return false, err
+		}
+		cfg.Dialector = nil
+		switch {
+		case !cfg.resolveAtOpen():
+			cfg.DSN = dsn
+			cfg.Dialector = dialector
+		case explicit:
+			cfg.DSN = dsn
+		default:
+			// 密钥引用在打开连接时才解析，由 opener 根据 SearchPath 生成 DSN
+		}
+	}
+
+	return group.Register(ctx, toName, cfg)
+}
+
+// setSearchPathInDSN 通过 DriverSpec.SetSearchPath 在 dsn 中设置 c.SearchPath
+func (c *DBConfig) setSearchPathInDSN(dsn string) (string, error) {
+	spec, err := c.explicitDSNSpec()
+	if err != nil {
+		return "", err
+	}
+	return editDSN(c.DriverType, "search_path", spec.SetSearchPath, dsn, c.SearchPath)
+}
+
+// MustRegisterToSchema 与 RegisterToSchema 相同，失败时 panic。
+func MustRegisterToSchema(ctx context.Context, group Group, fromName, toName string, sc SchemaConfig) (isNew bool) {
+	isNew, err := RegisterToSchema(ctx, group, fromName, toName, sc)
+	if err != nil {
+		panic(err)
+	}
+	return isNew
+}
+
+// openShared 使用来源连接 src 的方言与 pool 打开 *gorm.DB，使用 c 的 GORM 配置（如表名前缀）
+func (c *DBConfig) openShared(src *gorm.DB, pool *sharedConnPool) (*gorm.DB, error) {
+	db, err := gorm.Open(sharedPoolDialector{Dialector: src.Dialector, pool: pool}, c.BuildGormConfig())
+	if err != nil {
+		return nil, err
+	}
+	// 恢复原始方言，保留其实现的 SavePoint、Translate 等可选接口
+	db.Dialector = src.Dialector
+	if err := db.Use(sharedPool{}); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// sharedPoolDialector 由原始方言完成初始化（注册回调等），再改用共享的连接池
+type sharedPoolDialector struct {
+	gorm.Dialector
+	pool *sharedConnPool // pool 共享的连接池
+}
+
+// Initialize 实现 gorm.Dialector
+func (d sharedPoolDialector) Initialize(db *gorm.DB) error {
+	if err := d.Dialector.Initialize(db); err != nil {
+		return err
+	}
+	// 原始方言初始化时打开的连接池不再使用
+	if sqlDB, ok := db.ConnPool.(*sql.DB); ok {
+		if current, err := d.pool.GetDBConn(); err != nil || sqlDB != current {
+			_ = sqlDB.Close()
+		}
+	}
+	db.ConnPool = d.pool
+	return nil
+}
+
+// sharedConnPool 共享的连接池：每次使用时通过 Get 获取来源连接当前的连接池，
+// 来源连接被轮换、淘汰后重新打开时自动改用新的连接池，不会继续使用已关闭的连接池
+type sharedConnPool struct {
+	group *group
+	name  string // name 来源连接名
+}
+
+// pool 返回来源连接当前的连接池，来源连接已被淘汰时重新打开
+func (p *sharedConnPool) pool(ctx context.Context) (*sql.DB, error) {
+	src, err := p.group.Get(ctx, p.name)
+	if err != nil {
+		return nil, err
+	}
+	return src.DB()
+}
+
+// GetDBConn 实现 gorm.GetDBConnector，使 DB() 返回来源连接当前的连接池
+func (p *sharedConnPool) GetDBConn() (*sql.DB, error) {
+	return p.pool(context.Background())
+}
+
+// PrepareContext 实现 gorm.ConnPool
+func (p *sharedConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.PrepareContext(ctx, query)
+}
+
+// ExecContext 实现 gorm.ConnPool
+func (p *sharedConnPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.ExecContext(ctx, query, args...)
+}
+
+// QueryContext 实现 gorm.ConnPool
+func (p *sharedConnPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext 实现 gorm.ConnPool
+func (p *sharedConnPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		return errRow(ctx, err)
+	}
+	return pool.QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx 实现 gorm.TxBeginner
+func (p *sharedConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	pool, err := p.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.BeginTx(ctx, opts)
+}
+
+// errRow 返回 Scan 时返回 err 的 *sql.Row。sql.Row 无法直接构造，因此通过连接总是失败的连接池查询得到
+func errRow(ctx context.Context, err error) *sql.Row {
+	db := sql.OpenDB(errConnector{err: err})
+	defer db.Close()
+	return db.QueryRowContext(ctx, "")
+}
+
+// errConnector 总是返回 err 的 driver.Connector
+type errConnector struct {
+	err error
+}
+
+// Connect 实现 driver.Connector
+func (c errConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, c.err
+}
+
+// Driver 实现 driver.Connector
+func (c errConnector) Driver() driver.Driver {
+	return c
+}
+
+// Open 实现 driver.Driver
+func (c errConnector) Open(string) (driver.Conn, error) {
+	return nil, c.err
+}
+
+// sharedPool 标记连接与其他连接共用连接池，关闭连接时不关闭连接池
+type sharedPool struct{}
+
+// Name 实现 gorm.Plugin
+func (sharedPool) Name() string {
+	return sharedPoolName
+}
+
+// Initialize 实现 gorm.Plugin
+func (sharedPool) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// isSharedPool 判断 db 是否与其他连接共用连接池
+func isSharedPool(db *gorm.DB) bool {
+	_, ok := db.Config.Plugins[sharedPoolName]
+	return ok
+}
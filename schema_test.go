@@ -0,0 +1,189 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qq1060656096/bizutil/registry"
+)
+
+// schemaUser 用于验证表名前缀的模型
+type schemaUser struct {
+	ID   uint
+	Name string
+}
+
+// TestRegisterToSchema_TablePrefix 测试独立连接池模式下通过表名前缀隔离
+func TestRegisterToSchema_TablePrefix(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "main", DBConfig{DriverType: "sqlite", DBName: filepath.Join(t.TempDir(), "main.db")})
+
+	isNew, err := RegisterToSchema(ctx, group, "main", "tenant_a", SchemaConfig{TablePrefix: "a_"})
+	if err != nil || !isNew {
+		t.Fatalf("RegisterToSchema() = %v, %v", isNew, err)
+	}
+	tenant := group.MustGet(ctx, "tenant_a")
+	if tenant == group.MustGet(ctx, "main") {
+		t.Fatal("独立连接池模式应打开新的连接")
+	}
+	if err := tenant.AutoMigrate(&schemaUser{}); err != nil {
+		t.Fatalf("AutoMigrate() 失败: %v", err)
+	}
+	if !group.MustGet(ctx, "main").Migrator().HasTable("a_schema_users") {
+		t.Error("应创建带前缀的表 a_schema_users")
+	}
+
+	// SQLite 的 DSN 不支持 search_path
+	if _, err := RegisterToSchema(ctx, group, "main", "tenant_b", SchemaConfig{Schema: "b"}); err == nil {
+		t.Error("驱动不支持 search_path 时应返回错误")
+	}
+	if _, err := RegisterToSchema(ctx, group, "main", "tenant_c", SchemaConfig{}); err == nil {
+		t.Error("缺少 Schema 与 TablePrefix 时应返回错误")
+	}
+	if _, err := RegisterToSchema(ctx, group, "main", "tenant_d", SchemaConfig{Schema: "x; drop", SharedPool: true}); err == nil {
+		t.Error("非法的 schema 名应返回错误")
+	}
+}
+
+// TestRegisterToSchema_SearchPath 测试独立连接池模式下通过驱动写入 search_path，包括显式设置的 DSN
+func TestRegisterToSchema_SearchPath(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "fields", DBConfig{DriverType: "postgres", Host: "db.internal", Port: 5432, User: "app", DBName: "app"})
+	group.Register(ctx, "dsn", DBConfig{DriverType: "postgres", DSN: "host=db.internal user=app dbname=app search_path=tenant_a"})
+	group.Register(ctx, "url", DBConfig{DriverType: "postgres", DSN: "postgres://app@db.internal:5432/app?sslmode=disable"})
+
+	MustRegisterToSchema(ctx, group, "fields", "tenant_fields", SchemaConfig{Schema: "tenant"})
+	if dsn := group.MustConfig(ctx, "tenant_fields").DSN; !strings.HasSuffix(dsn, " search_path=tenant") || !strings.Contains(dsn, "host=db.internal") {
+		t.Errorf("根据字段生成的 DSN = %q", dsn)
+	}
+	// 来源 DSN 中已有的 search_path 被替换，不因包含 "search_path=tenant" 而被误判为已设置
+	MustRegisterToSchema(ctx, group, "dsn", "tenant_dsn", SchemaConfig{Schema: "tenant"})
+	if dsn := group.MustConfig(ctx, "tenant_dsn").DSN; dsn != "host=db.internal user=app dbname=app search_path=tenant" {
+		t.Errorf("显式 DSN = %q", dsn)
+	}
+	MustRegisterToSchema(ctx, group, "url", "tenant_url", SchemaConfig{Schema: "tenant"})
+	if dsn := group.MustConfig(ctx, "tenant_url").DSN; dsn != "postgres://app@db.internal:5432/app?search_path=tenant&sslmode=disable" {
+		t.Errorf("URL 形式的 DSN = %q", dsn)
+	}
+}
+
+// TestRegisterToSchema_SharedPool 测试共享连接池模式
+func TestRegisterToSchema_SharedPool(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "main", DBConfig{DriverType: "sqlite", DBName: filepath.Join(t.TempDir(), "main.db")})
+
+	// SQLite 的默认 schema 为 main
+	if _, err := RegisterToSchema(ctx, group, "main", "tenant_a", SchemaConfig{Schema: "main", TablePrefix: "a_", SharedPool: true}); err != nil {
+		t.Fatalf("RegisterToSchema() 失败: %v", err)
+	}
+	if cfg := group.MustConfig(ctx, "tenant_a"); cfg.TablePrefix != "main.a_" {
+		t.Errorf("TablePrefix = %q, 期望 main.a_", cfg.TablePrefix)
+	}
+
+	main := group.MustGet(ctx, "main")
+	tenant := group.MustGet(ctx, "tenant_a")
+	mainPool, _ := main.DB()
+	tenantPool, _ := tenant.DB()
+	if mainPool != tenantPool {
+		t.Fatal("共享连接池模式应复用来源连接的连接池")
+	}
+
+	if err := tenant.AutoMigrate(&schemaUser{}); err != nil {
+		t.Fatalf("AutoMigrate() 失败: %v", err)
+	}
+	if err := tenant.Create(&schemaUser{Name: "alice"}).Error; err != nil {
+		t.Fatalf("Create() 失败: %v", err)
+	}
+	var count int64
+	main.Table("a_schema_users").Count(&count)
+	if count != 1 {
+		t.Errorf("来源连接应能看到租户写入的数据，count = %d", count)
+	}
+
+	// 注销租户连接不关闭共享的连接池
+	if err := group.Unregister(ctx, "tenant_a"); err != nil {
+		t.Fatalf("Unregister() 失败: %v", err)
+	}
+	if err := mainPool.PingContext(ctx); err != nil {
+		t.Errorf("注销租户连接后来源连接池应仍可用: %v", err)
+	}
+}
+
+// TestRegisterToSchema_SharedPoolSourceLifecycle 测试来源连接被轮换、淘汰或注销后共享连接池的租户连接
+func TestRegisterToSchema_SharedPoolSourceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	g, err := NewEvictingGroup(New(), EvictionConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close(ctx)
+	g.Register(ctx, "main", DBConfig{DriverType: "sqlite", DBName: filepath.Join(t.TempDir(), "main.db")})
+	MustRegisterToSchema(ctx, g, "main", "tenant_a", SchemaConfig{TablePrefix: "a_", SharedPool: true})
+
+	tenant := g.MustGet(ctx, "tenant_a")
+	if err := tenant.AutoMigrate(&schemaUser{}); err != nil {
+		t.Fatalf("AutoMigrate() 失败: %v", err)
+	}
+
+	// 来源连接轮换或淘汰后，已打开的租户连接自动改用新的连接池
+	if err := Rotate(ctx, g, "main"); err != nil {
+		t.Fatalf("Rotate() 失败: %v", err)
+	}
+	if err := tenant.Create(&schemaUser{Name: "alice"}).Error; err != nil {
+		t.Fatalf("来源连接轮换后租户连接应可用: %v", err)
+	}
+	if err := g.Evict(ctx, "main"); err != nil {
+		t.Fatalf("Evict() 失败: %v", err)
+	}
+	var count int64
+	if err := tenant.Model(&schemaUser{}).Count(&count).Error; err != nil || count != 1 {
+		t.Fatalf("来源连接被淘汰后租户连接应可用: %d, %v", count, err)
+	}
+	mainPool, _ := g.MustGet(ctx, "main").DB()
+	if tenantPool, _ := tenant.DB(); tenantPool != mainPool {
+		t.Error("租户连接应使用来源连接当前的连接池")
+	}
+
+	// 来源连接注销后返回未注册的错误，而不是使用已关闭的连接池
+	if err := g.Unregister(ctx, "main"); err != nil {
+		t.Fatalf("Unregister() 失败: %v", err)
+	}
+	err = tenant.Model(&schemaUser{}).Count(&count).Error
+	if !errors.Is(err, registry.ErrResourceNotFound) {
+		t.Errorf("来源连接注销后应返回 ErrResourceNotFound，实际为: %v", err)
+	}
+}
+
+// TestRegisterToDB_FromSharedPool 测试复制 SharedPool 模式的连接时新数据库使用独立的连接池
+func TestRegisterToDB_FromSharedPool(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "main", DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, "main.db")})
+	MustRegisterToSchema(ctx, group, "main", "tenant_a", SchemaConfig{TablePrefix: "a_", SharedPool: true})
+
+	MustRegisterToDB(ctx, group, "tenant_a", "tenant_b", filepath.Join(dir, "tenant_b.db"))
+	tenantB := group.MustGet(ctx, "tenant_b")
+	if err := tenantB.AutoMigrate(&schemaUser{}); err != nil {
+		t.Fatalf("AutoMigrate() 失败: %v", err)
+	}
+
+	mainPool, _ := group.MustGet(ctx, "main").DB()
+	if pool, _ := tenantB.DB(); pool == mainPool {
+		t.Error("复制的连接应使用独立的连接池")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tenant_b.db")); err != nil {
+		t.Errorf("复制的连接应使用新的数据库: %v", err)
+	}
+}
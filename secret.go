@@ -0,0 +1,161 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 内置密钥提供者的 scheme
+const (
+	SecretSchemeEnv  = "env"  // SecretSchemeEnv 从环境变量读取，如 env:DB_PASS
+	SecretSchemeFile = "file" // SecretSchemeFile 从文件读取，如 file:/run/secrets/db
+)
+
+// ErrSecretNotFound 密钥引用指向的环境变量不存在时返回此错误，自定义 SecretProvider 也可以返回此错误。
+var ErrSecretNotFound = errors.New("mgorm: secret not found")
+
+// SecretProvider 根据引用解析密钥，用于在打开连接时才读取密码，使密码不出现在配置文件中。
+//
+// DBConfig.Password、DBConfig.DSN 以及从库的 Password、DSN 可以写成 <scheme>:<ref> 形式的引用，
+// scheme 为 RegisterSecretProvider 注册的名称，Resolve 收到的是 ref 部分。
+// Resolve 在 Get 首次打开连接时调用，会阻塞该次 Get，应设置超时。
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretProviderFunc 将函数适配为 SecretProvider
+type SecretProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve 实现 SecretProvider
+func (f SecretProviderFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// EnvSecretProvider 从环境变量读取密钥，ref 为环境变量名，环境变量不存在时返回 ErrSecretNotFound
+type EnvSecretProvider struct{}
+
+// Resolve 实现 SecretProvider
+func (EnvSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrSecretNotFound, ref)
+	}
+	return value, nil
+}
+
+// FileSecretProvider 从文件读取密钥，ref 为文件路径，去掉文件末尾的换行符，
+// 适用于 Docker、Kubernetes 挂载的 secret 文件
+type FileSecretProvider struct{}
+
+// Resolve 实现 SecretProvider
+func (FileSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	content, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// secretRegistry 已注册的密钥提供者，key 为 scheme
+var secretRegistry = struct {
+	sync.RWMutex
+	providers map[string]SecretProvider
+}{
+	providers: map[string]SecretProvider{
+		SecretSchemeEnv:  EnvSecretProvider{},
+		SecretSchemeFile: FileSecretProvider{},
+	},
+}
+
+// RegisterSecretProvider 以 scheme 注册密钥提供者，如注册 "vault" 后可以使用 vault:secret/data/db#password。
+// 重复注册同一 scheme 会覆盖之前的提供者，可用于替换内置的 env、file。
+// scheme 为空、包含 ":" 或 provider 为 nil 时 panic。
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	if scheme == "" || strings.Contains(scheme, ":") {
+		panic("mgorm: RegisterSecretProvider invalid scheme " + scheme)
+	}
+	if provider == nil {
+		panic("mgorm: RegisterSecretProvider provider is nil for scheme " + scheme)
+	}
+
+	secretRegistry.Lock()
+	defer secretRegistry.Unlock()
+	secretRegistry.providers[scheme] = provider
+}
+
+// SecretSchemes 返回已注册的密钥提供者 scheme，按字典序排列。
+func SecretSchemes() []string {
+	secretRegistry.RLock()
+	defer secretRegistry.RUnlock()
+	return sortedKeys(secretRegistry.providers)
+}
+
+// lookupSecretProvider 返回 value 的 scheme 对应的密钥提供者与 ref，value 不是引用时 ok 为 false
+func lookupSecretProvider(value string) (provider SecretProvider, ref string, ok bool) {
+	scheme, ref, found := strings.Cut(value, ":")
+	if !found || scheme == "" {
+		return nil, "", false
+	}
+	secretRegistry.RLock()
+	defer secretRegistry.RUnlock()
+	provider, ok = secretRegistry.providers[scheme]
+	return provider, ref, ok
+}
+
+// IsSecretRef 判断 value 是否为已注册 scheme 的密钥引用
+func IsSecretRef(value string) bool {
+	_, _, ok := lookupSecretProvider(value)
+	return ok
+}
+
+// ResolveSecret 解析密钥引用：value 以已注册的 scheme 加 ":" 开头时返回提供者解析出的值，否则原样返回。
+// 因此未注册 scheme 的 "user:pass@tcp(...)" 等值不受影响；
+// 字面值恰好以已注册 scheme 开头时（如密码 "env:abc"）需要改用引用提供。
+func ResolveSecret(ctx context.Context, value string) (string, error) {
+	provider, ref, ok := lookupSecretProvider(value)
+	if !ok {
+		return value, nil
+	}
+	secret, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		// 错误中只包含引用，不包含密钥
+		return "", fmt.Errorf("mgorm: resolve secret %q: %w", value, err)
+	}
+	return secret, nil
+}
+
+// resolveSecrets 返回 Password 与 DSN 中的密钥引用替换为实际值后的副本，c 本身不会被修改。
+// 驱动的 DriverSpec.LiteralDSN 为 true 时 DSN 不作为引用解析。
+// 从库的引用在 replicaConfigs 生成各自的配置后分别解析。
+func (c DBConfig) resolveSecrets(ctx context.Context) (DBConfig, error) {
+	password, err := ResolveSecret(ctx, c.Password)
+	if err != nil {
+		return DBConfig{}, fmt.Errorf("password: %w", err)
+	}
+	c.Password = password
+
+	if !c.literalDSN() {
+		dsn, err := ResolveSecret(ctx, c.DSN)
+		if err != nil {
+			return DBConfig{}, fmt.Errorf("dsn: %w", err)
+		}
+		c.DSN = dsn
+	}
+	return c, nil
+}
+
+// resolveAtOpen 判断 DSN 是否需要在打开连接时才生成：Password 或 DSN 为密钥引用，或设置了 DynamicCredentials。
+// 此时注册时不能预先根据 DSN 创建 Dialector。
+func (c *DBConfig) resolveAtOpen() bool {
+	return c.DynamicCredentials || IsSecretRef(c.Password) || (!c.literalDSN() && IsSecretRef(c.DSN))
+}
+
+// literalDSN 判断驱动的 DSN 是否不作为密钥引用解析
+func (c *DBConfig) literalDSN() bool {
+	spec, ok := lookupDriver(c.DriverType)
+	return ok && spec.LiteralDSN
+}
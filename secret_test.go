@@ -0,0 +1,195 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+)
+
+// registerSecretSQLiteDriver 注册 DSN 中包含密码的 SQLite 驱动：文件路径为 Host/DBName_Password.db，
+// 用于验证打开连接时使用的是解析后的密码
+func registerSecretSQLiteDriver(t *testing.T) {
+	RegisterDriver("secret_sqlite", DriverSpec{
+		Dialector: sqlite.Open,
+		DSN: func(cfg *DBConfig) string {
+			return filepath.Join(cfg.Host, cfg.DBName+"_"+cfg.Password+".db")
+		},
+		RequiredFields: []string{"host", "db_name"},
+	})
+	t.Cleanup(func() { unregisterDriver("secret_sqlite") })
+}
+
+// unregisterSecretProvider 注销密钥提供者（仅用于测试）
+func unregisterSecretProvider(scheme string) {
+	secretRegistry.Lock()
+	defer secretRegistry.Unlock()
+	delete(secretRegistry.providers, scheme)
+}
+
+// TestResolveSecret 测试内置的 env、file 提供者以及非引用的值原样返回
+func TestResolveSecret(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("MGORM_TEST_SECRET", "s3cret")
+	secretFile := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"env:MGORM_TEST_SECRET", "s3cret"},
+		{"file:" + secretFile, "from-file"},
+		{"plain", "plain"},
+		{"", ""},
+		{"root:pass@tcp(127.0.0.1:3306)/app", "root:pass@tcp(127.0.0.1:3306)/app"},
+		{"vault:secret/db", "vault:secret/db"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveSecret(ctx, tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveSecret(%q) = %q, %v, 期望 %q", tt.value, got, err, tt.want)
+		}
+	}
+
+	_, err := ResolveSecret(ctx, "env:MGORM_TEST_SECRET_MISSING")
+	if !errors.Is(err, ErrSecretNotFound) || !strings.Contains(err.Error(), "env:MGORM_TEST_SECRET_MISSING") {
+		t.Errorf("环境变量不存在时应返回 ErrSecretNotFound 并包含引用，实际为: %v", err)
+	}
+	if _, err := ResolveSecret(ctx, "file:"+secretFile+".missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("文件不存在时应返回 os.ErrNotExist，实际为: %v", err)
+	}
+}
+
+// TestRegisterSecretProvider 测试注册自定义 scheme 的提供者
+func TestRegisterSecretProvider(t *testing.T) {
+	ctx := context.Background()
+	RegisterSecretProvider("vault", SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		if ref != "secret/db#password" {
+			return "", ErrSecretNotFound
+		}
+		return "from-vault", nil
+	}))
+	t.Cleanup(func() { unregisterSecretProvider("vault") })
+
+	if schemes := strings.Join(SecretSchemes(), ","); schemes != "env,file,vault" {
+		t.Errorf("SecretSchemes() = %q", schemes)
+	}
+	if !IsSecretRef("vault:secret/db#password") || IsSecretRef("consul:x") {
+		t.Error("IsSecretRef 应只识别已注册的 scheme")
+	}
+	if got, err := ResolveSecret(ctx, "vault:secret/db#password"); err != nil || got != "from-vault" {
+		t.Errorf("ResolveSecret() = %q, %v", got, err)
+	}
+
+	for _, scheme := range []string{"", "a:b"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterSecretProvider(%q) 应 panic", scheme)
+				}
+			}()
+			RegisterSecretProvider(scheme, EnvSecretProvider{})
+		}()
+	}
+}
+
+// TestOpener_SecretRef 测试 opener 打开连接时解析密码引用，注册的配置中仍然保存引用
+func TestOpener_SecretRef(t *testing.T) {
+	ctx := context.Background()
+	registerSecretSQLiteDriver(t)
+	dir := t.TempDir()
+	t.Setenv("MGORM_TEST_SECRET", "s3cret")
+
+	group := New()
+	defer group.Close(ctx)
+	cfg := DBConfig{DriverType: "secret_sqlite", Host: dir, DBName: "app", Password: "env:MGORM_TEST_SECRET"}
+	group.Register(ctx, "app", cfg)
+	if _, err := group.Get(ctx, "app"); err != nil {
+		t.Fatalf("Get() 失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app_s3cret.db")); err != nil {
+		t.Errorf("应使用解析后的密码打开连接: %v", err)
+	}
+	if registered := group.MustConfig(ctx, "app"); registered.Password != "env:MGORM_TEST_SECRET" {
+		t.Errorf("注册的配置中应保存引用，实际为 %q", registered.Password)
+	}
+
+	// RegisterToDB 复制的配置同样在打开时解析
+	MustRegisterToDB(ctx, group, "app", "tenant", "tenant")
+	if _, err := group.Get(ctx, "tenant"); err != nil {
+		t.Fatalf("Get(tenant) 失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tenant_s3cret.db")); err != nil {
+		t.Errorf("RegisterToDB 的连接应使用解析后的密码: %v", err)
+	}
+
+	// 引用无法解析时打开失败，错误中包含字段名
+	group.Register(ctx, "missing", DBConfig{DriverType: "secret_sqlite", Host: dir, DBName: "missing", Password: "env:MGORM_TEST_SECRET_MISSING"})
+	_, err := group.Get(ctx, "missing")
+	if !errors.Is(err, ErrSecretNotFound) || !strings.Contains(err.Error(), "password") {
+		t.Errorf("引用无法解析时应返回 ErrSecretNotFound，实际为: %v", err)
+	}
+}
+
+// TestOpener_SecretDSN 测试 DSN 整体作为引用，以及 LiteralDSN 驱动的 "file:" DSN 不被解析
+func TestOpener_SecretDSN(t *testing.T) {
+	ctx := context.Background()
+	registerSecretSQLiteDriver(t)
+	dir := t.TempDir()
+	dsnFile := filepath.Join(dir, "dsn")
+	if err := os.WriteFile(dsnFile, []byte(filepath.Join(dir, "from_secret.db")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "ref", DBConfig{DriverType: "secret_sqlite", DSN: "file:" + dsnFile})
+	group.Register(ctx, "literal", DBConfig{DriverType: "sqlite", DSN: "file:" + filepath.Join(dir, "literal.db")})
+
+	for _, name := range []string{"ref", "literal"} {
+		if _, err := group.Get(ctx, name); err != nil {
+			t.Fatalf("Get(%s) 失败: %v", name, err)
+		}
+	}
+	for _, file := range []string{"from_secret.db", "literal.db"} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("%s 应被创建: %v", file, err)
+		}
+	}
+}
+
+// TestLoadManager_SecretRef 测试从配置文件加载的连接在打开时解析密钥引用
+func TestLoadManager_SecretRef(t *testing.T) {
+	ctx := context.Background()
+	registerSecretSQLiteDriver(t)
+	dir := t.TempDir()
+	t.Setenv("MGORM_TEST_SECRET", "s3cret")
+
+	manager, err := LoadManager(ctx, ManagerConfig{
+		"business": {
+			"app": {DriverType: "secret_sqlite", Host: dir, DBName: "app", Password: "env:MGORM_TEST_SECRET"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("LoadManager() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	group := manager.MustGroup("business")
+	if cfg := group.MustConfig(ctx, "app"); cfg.Dialector != nil {
+		t.Error("密钥引用不应在注册时生成 Dialector")
+	}
+	if _, err := group.Get(ctx, "app"); err != nil {
+		t.Fatalf("Get() 失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app_s3cret.db")); err != nil {
+		t.Errorf("应使用解析后的密码打开连接: %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidShardKey 当分片键无法被分片策略处理（类型不支持、超出范围表等）时返回此错误。
+var ErrInvalidShardKey = errors.New("mgorm: invalid shard key")
+
+// ShardStrategy 分片策略，根据分片键选择分片序号
+type ShardStrategy interface {
+	// Shard 返回 key 所在的分片序号，取值范围为 [0, count)
+	Shard(key any, count int) (int, error)
+}
+
+// ShardConfig 分片配置
+type ShardConfig struct {
+	// BaseName 作为模板的连接名，各分片通过 RegisterToDB 复制该连接的配置
+	BaseName string
+	// Count 分片数量
+	Count int
+	// NameFormat 分片连接名模板，使用 fmt 格式化分片编号，如 "order_%d"；为空时使用 BaseName + "_%d"
+	NameFormat string
+	// DBNameFormat 分片数据库名模板，如 "order_db_%d"；为空时与 NameFormat 相同
+	DBNameFormat string
+	// IndexBase 模板中分片编号的起始值，如为 1 时分片名为 order_1 … order_N
+	IndexBase int
+	// Strategy 分片策略，为 nil 时使用 ModuloStrategy
+	Strategy ShardStrategy
+}
+
+// ShardRouter 把业务分片键路由到 Group 中对应分片的连接
+type ShardRouter struct {
+	group    Group
+	names    []string
+	strategy ShardStrategy
+}
+
+// NewShardRouter 根据 cfg 通过 RegisterToDB 把 BaseName 的配置注册为 Count 个分片，并返回分片路由。
+// 已注册的分片名不会被覆盖（与 RegisterToDB 一致），因此重复创建同一配置的路由是安全的。
+// 任一分片注册失败时注销本次新注册的分片，返回合并后的错误。
+func NewShardRouter(ctx context.Context, group Group, cfg ShardConfig) (*ShardRouter, error) {
+	if cfg.Count <= 0 {
+		return nil, fmt.Errorf("mgorm: shard count must be positive, got %d", cfg.Count)
+	}
+	nameFormat := cfg.NameFormat
+	if nameFormat == "" {
+		nameFormat = cfg.BaseName + "_%d"
+	}
+	dbNameFormat := cfg.DBNameFormat
+	if dbNameFormat == "" {
+		dbNameFormat = nameFormat
+	}
+	for _, format := range []string{nameFormat, dbNameFormat} {
+		if err := checkShardFormat(format); err != nil {
+			return nil, err
+		}
+	}
+	strategy := cfg.Strategy
+	if strategy == nil {
+		strategy = ModuloStrategy{}
+	}
+
+	r := &ShardRouter{group: group, names: make([]string, cfg.Count), strategy: strategy}
+	var (
+		errs  []error
+		added []string
+	)
+	for i := 0; i < cfg.Count; i++ {
+		n := cfg.IndexBase + i
+		r.names[i] = fmt.Sprintf(nameFormat, n)
+		isNew, err := RegisterToDB(ctx, group, cfg.BaseName, r.names[i], fmt.Sprintf(dbNameFormat, n))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shard %s: %w", r.names[i], err))
+			continue
+		}
+		if isNew {
+			added = append(added, r.names[i])
+		}
+	}
+	if len(errs) > 0 {
+		for _, name := range added {
+			_ = group.Unregister(ctx, name)
+		}
+		return nil, errors.Join(errs...)
+	}
+	return r, nil
+}
+
+// checkShardFormat 检查分片名模板能否为不同的分片编号生成不同的名称，如缺少 %d 的 "order" 不能
+func checkShardFormat(format string) error {
+	first, second := fmt.Sprintf(format, 0), fmt.Sprintf(format, 1)
+	if first == second || strings.Contains(first, "%!") {
+		return fmt.Errorf("mgorm: shard name format %q must contain exactly one verb for the shard index, such as %%d", format)
+	}
+	return nil
+}
+
+// Names 返回按分片序号排列的分片连接名
+func (r *ShardRouter) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+// Shard 返回 key 所在分片的连接名
+func (r *ShardRouter) Shard(key any) (string, error) {
+	i, err := r.strategy.Shard(key, len(r.names))
+	if err != nil {
+		return "", err
+	}
+	if i < 0 || i >= len(r.names) {
+		return "", fmt.Errorf("%w: strategy returned shard %d out of [0, %d)", ErrInvalidShardKey, i, len(r.names))
+	}
+	return r.names[i], nil
+}
+
+// For 返回 key 所在分片的数据库连接（首次调用时创建）
+func (r *ShardRouter) For(ctx context.Context, key any) (*gorm.DB, error) {
+	name, err := r.Shard(key)
+	if err != nil {
+		return nil, err
+	}
+	return r.group.Get(ctx, name)
+}
+
+// MustFor 返回 key 所在分片的数据库连接，失败时 panic
+func (r *ShardRouter) MustFor(ctx context.Context, key any) *gorm.DB {
+	db, err := r.For(ctx, key)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// shardKeyInt 把整数类型的分片键转换为 uint64，负数取绝对值
+func shardKeyInt(key any) (uint64, bool) {
+	v, _, ok := shardKeySigned(key)
+	return v, ok
+}
+
+// shardKeySigned 把整数类型的分片键转换为绝对值与符号
+func shardKeySigned(key any) (abs uint64, negative bool, ok bool) {
+	var v int64
+	switch k := key.(type) {
+	case int:
+		v = int64(k)
+	case int8:
+		v = int64(k)
+	case int16:
+		v = int64(k)
+	case int32:
+		v = int64(k)
+	case int64:
+		v = k
+	case uint:
+		return uint64(k), false, true
+	case uint8:
+		return uint64(k), false, true
+	case uint16:
+		return uint64(k), false, true
+	case uint32:
+		return uint64(k), false, true
+	case uint64:
+		return k, false, true
+	default:
+		return 0, false, false
+	}
+	if v < 0 {
+		return uint64(-v), true, true
+	}
+	return uint64(v), false, true
+}
+
+// shardKeyBytes 返回分片键用于哈希的字节，整数使用十进制表示
+func shardKeyBytes(key any) ([]byte, error) {
+	switch k := key.(type) {
+	case string:
+		return []byte(k), nil
+	case []byte:
+		return k, nil
+	case fmt.Stringer:
+		return []byte(k.String()), nil
+	}
+	if v, ok := shardKeyInt(key); ok {
+		return strconv.AppendUint(nil, v, 10), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported key type %T", ErrInvalidShardKey, key)
+}
+
+// hashKey 使用 FNV-1a 计算分片键的哈希值
+func hashKey(key any) (uint64, error) {
+	b, err := shardKeyBytes(key)
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	h.Write(b)
+	return h.Sum64(), nil
+}
+
+// ModuloStrategy 取模分片：整数键直接对分片数取模（负数取绝对值），
+// 字符串等其他键先计算 FNV-1a 哈希再取模。分片数变化时大部分键会迁移到其他分片。
+type ModuloStrategy struct{}
+
+// Shard 实现 ShardStrategy
+func (ModuloStrategy) Shard(key any, count int) (int, error) {
+	if count <= 0 {
+		return 0, fmt.Errorf("%w: shard count must be positive, got %d", ErrInvalidShardKey, count)
+	}
+	if v, ok := shardKeyInt(key); ok {
+		return int(v % uint64(count)), nil
+	}
+	h, err := hashKey(key)
+	if err != nil {
+		return 0, err
+	}
+	return int(h % uint64(count)), nil
+}
+
+// DefaultVirtualNodes 一致性哈希中每个分片默认的虚拟节点数
+const DefaultVirtualNodes = 160
+
+// ConsistentHashStrategy 一致性哈希分片：分片数变化时只有约 1/N 的键会迁移，适合需要扩容的场景。
+// 零值可以直接使用，每个分片使用 DefaultVirtualNodes 个虚拟节点。
+type ConsistentHashStrategy struct {
+	virtualNodes int
+
+	mu    sync.Mutex
+	rings map[int]*hashRing // rings 按分片数缓存的哈希环
+}
+
+// hashRing 一致性哈希环
+type hashRing struct {
+	points []uint64       // points 排序后的虚拟节点哈希值
+	shards map[uint64]int // shards 虚拟节点哈希值到分片序号的映射
+}
+
+// NewConsistentHashStrategy 创建一致性哈希分片策略，virtualNodes 为每个分片的虚拟节点数，
+// <=0 时使用 DefaultVirtualNodes。虚拟节点越多分布越均匀。
+func NewConsistentHashStrategy(virtualNodes int) *ConsistentHashStrategy {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	return &ConsistentHashStrategy{virtualNodes: virtualNodes, rings: make(map[int]*hashRing)}
+}
+
+// Shard 实现 ShardStrategy
+func (s *ConsistentHashStrategy) Shard(key any, count int) (int, error) {
+	if count <= 0 {
+		return 0, fmt.Errorf("%w: shard count must be positive, got %d", ErrInvalidShardKey, count)
+	}
+	h, err := hashKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	ring := s.ring(count)
+	i := sort.Search(len(ring.points), func(i int) bool { return ring.points[i] >= h })
+	if i == len(ring.points) {
+		i = 0
+	}
+	return ring.shards[ring.points[i]], nil
+}
+
+// ring 返回 count 个分片的哈希环
+func (s *ConsistentHashStrategy) ring(count int) *hashRing {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ring, ok := s.rings[count]; ok {
+		return ring
+	}
+	if s.rings == nil {
+		s.rings = make(map[int]*hashRing)
+	}
+	virtualNodes := s.virtualNodes
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+
+	ring := &hashRing{shards: make(map[uint64]int, count*virtualNodes)}
+	for shard := 0; shard < count; shard++ {
+		for v := 0; v < virtualNodes; v++ {
+			h, _ := hashKey("shard-" + strconv.Itoa(shard) + "#" + strconv.Itoa(v))
+			if _, ok := ring.shards[h]; ok {
+				continue
+			}
+			ring.shards[h] = shard
+			ring.points = append(ring.points, h)
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+	s.rings[count] = ring
+	return ring
+}
+
+// ShardRange 范围表中的一项：小于 Upper 且不小于上一项 Upper 的键属于 Shard
+type ShardRange struct {
+	Upper uint64 // Upper 范围上界（不包含）
+	Shard int    // Shard 分片序号
+}
+
+// RangeStrategy 范围表分片：按整数键所在的区间选择分片，适合按用户 ID 段等有序键分片。
+// Ranges 必须按 Upper 升序排列，负数键与超出最后一项 Upper 的键返回 ErrInvalidShardKey。
+type RangeStrategy struct {
+	Ranges []ShardRange
+}
+
+// Shard 实现 ShardStrategy
+func (s RangeStrategy) Shard(key any, count int) (int, error) {
+	v, negative, ok := shardKeySigned(key)
+	if !ok {
+		return 0, fmt.Errorf("%w: range strategy requires an integer key, got %T", ErrInvalidShardKey, key)
+	}
+	if negative {
+		return 0, fmt.Errorf("%w: range strategy requires a non-negative key, got -%d", ErrInvalidShardKey, v)
+	}
+
+	i := sort.Search(len(s.Ranges), func(i int) bool { return v < s.Ranges[i].Upper })
+	if i == len(s.Ranges) {
+		return 0, fmt.Errorf("%w: key %d is out of range table", ErrInvalidShardKey, v)
+	}
+	return s.Ranges[i].Shard, nil
+}
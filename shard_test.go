@@ -0,0 +1,203 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestNewShardRouter 测试注册所有分片并按分片键路由到对应连接
+func TestNewShardRouter(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	group := New()
+	defer group.Close(ctx)
+	group.Register(ctx, "order", DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, "order.db")})
+
+	router, err := NewShardRouter(ctx, group, ShardConfig{
+		BaseName:     "order",
+		Count:        4,
+		DBNameFormat: filepath.Join(dir, "order_%d.db"),
+		IndexBase:    1,
+	})
+	if err != nil {
+		t.Fatalf("NewShardRouter() 失败: %v", err)
+	}
+
+	if names := strings.Join(router.Names(), ","); names != "order_1,order_2,order_3,order_4" {
+		t.Errorf("分片名 = %s", names)
+	}
+	cfg := group.MustConfig(ctx, "order_3")
+	if cfg.DBName != filepath.Join(dir, "order_3.db") {
+		t.Errorf("order_3 的 DBName = %q", cfg.DBName)
+	}
+
+	// 默认使用取模策略：10 % 4 = 2，对应编号从 1 开始的 order_3
+	db, err := router.For(ctx, int64(10))
+	if err != nil {
+		t.Fatalf("For() 失败: %v", err)
+	}
+	if db != group.MustGet(ctx, "order_3") {
+		t.Error("For(10) 应返回 order_3 的连接")
+	}
+
+	if _, err := router.For(ctx, 1.5); !errors.Is(err, ErrInvalidShardKey) {
+		t.Errorf("不支持的分片键应返回 ErrInvalidShardKey，实际为: %v", err)
+	}
+
+	// 重复创建不会覆盖已注册的分片
+	if _, err := NewShardRouter(ctx, group, ShardConfig{BaseName: "order", Count: 4, IndexBase: 1}); err != nil {
+		t.Errorf("重复创建不应返回错误: %v", err)
+	}
+	if group.MustConfig(ctx, "order_3").DBName != cfg.DBName {
+		t.Error("重复创建不应覆盖已注册的分片")
+	}
+}
+
+// TestNewShardRouter_Errors 测试无效配置
+func TestNewShardRouter_Errors(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	defer group.Close(ctx)
+
+	if _, err := NewShardRouter(ctx, group, ShardConfig{BaseName: "order", Count: 0}); err == nil {
+		t.Error("分片数为 0 时应返回错误")
+	}
+	if _, err := NewShardRouter(ctx, group, ShardConfig{BaseName: "not_exists", Count: 2}); err == nil {
+		t.Error("BaseName 不存在时应返回错误")
+	}
+	for _, cfg := range []ShardConfig{
+		{BaseName: "order", Count: 2, NameFormat: "order"},
+		{BaseName: "order", Count: 2, DBNameFormat: "order_db_%s"},
+		{BaseName: "order", Count: 2, NameFormat: "order_%d_%d"},
+	} {
+		if _, err := NewShardRouter(ctx, group, cfg); err == nil {
+			t.Errorf("无法生成不同分片名的模板 %q/%q 应返回错误", cfg.NameFormat, cfg.DBNameFormat)
+		}
+	}
+	if names := group.List(); len(names) != 0 {
+		t.Errorf("模板无效时不应注册任何连接，实际为 %v", names)
+	}
+
+	// 部分分片注册失败时注销本次新注册的分片，已存在的分片保留
+	group.Register(ctx, "order", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+	group.Register(ctx, "order_0", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+	_, err := NewShardRouter(ctx, failingGroup{Group: group, failName: "order_2"}, ShardConfig{BaseName: "order", Count: 4})
+	if err == nil || !strings.Contains(err.Error(), "order_2") {
+		t.Fatalf("order_2 注册失败时应返回错误，实际为: %v", err)
+	}
+	if names := strings.Join(groupNames(group), ","); names != "order,order_0" {
+		t.Errorf("失败后应只保留原有的连接，实际为 %s", names)
+	}
+}
+
+// TestModuloStrategy 测试取模策略
+func TestModuloStrategy(t *testing.T) {
+	tests := []struct {
+		key  any
+		want int
+	}{
+		{0, 0},
+		{7, 3},
+		{int64(-7), 3},
+		{uint32(9), 1},
+	}
+	for _, tt := range tests {
+		if got, err := (ModuloStrategy{}).Shard(tt.key, 4); err != nil || got != tt.want {
+			t.Errorf("Shard(%v) = %d, %v, 期望 %d", tt.key, got, err, tt.want)
+		}
+	}
+
+	if _, err := (ModuloStrategy{}).Shard(7, 0); !errors.Is(err, ErrInvalidShardKey) {
+		t.Errorf("分片数为 0 时应返回 ErrInvalidShardKey，实际为: %v", err)
+	}
+
+	first, _ := ModuloStrategy{}.Shard("user-42", 4)
+	second, _ := ModuloStrategy{}.Shard("user-42", 4)
+	if first != second || first < 0 || first >= 4 {
+		t.Errorf("字符串键的分片应稳定且在范围内: %d, %d", first, second)
+	}
+}
+
+// TestConsistentHashStrategy 测试一致性哈希的稳定性与扩容时的迁移比例
+func TestConsistentHashStrategy(t *testing.T) {
+	s := NewConsistentHashStrategy(0)
+
+	const keys = 10000
+	moved := 0
+	counts := make([]int, 10)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		before, err := s.Shard(key, 10)
+		if err != nil {
+			t.Fatalf("Shard() 失败: %v", err)
+		}
+		if again, _ := s.Shard(key, 10); again != before {
+			t.Fatalf("同一键的分片应稳定: %d != %d", again, before)
+		}
+		counts[before]++
+
+		after, _ := s.Shard(key, 11)
+		if after != before {
+			moved++
+			if after != 10 {
+				t.Fatalf("扩容时键只应迁移到新分片，%s 从 %d 迁移到 %d", key, before, after)
+			}
+		}
+	}
+
+	// 理想迁移比例为 1/11
+	if ratio := float64(moved) / keys; ratio > 0.2 {
+		t.Errorf("扩容迁移比例 = %.2f，期望约 0.09", ratio)
+	}
+	for shard, n := range counts {
+		if n < keys/10/2 {
+			t.Errorf("分片 %d 只分到 %d 个键，分布不均匀", shard, n)
+		}
+	}
+}
+
+// TestConsistentHashStrategy_ZeroValue 测试零值可以直接使用，分片数无效时返回错误
+func TestConsistentHashStrategy_ZeroValue(t *testing.T) {
+	var s ConsistentHashStrategy
+	want, _ := NewConsistentHashStrategy(0).Shard("user-42", 4)
+	if got, err := s.Shard("user-42", 4); err != nil || got != want {
+		t.Errorf("零值 Shard() = %d, %v, 期望 %d", got, err, want)
+	}
+	if _, err := s.Shard("user-42", 0); !errors.Is(err, ErrInvalidShardKey) {
+		t.Errorf("分片数为 0 时应返回 ErrInvalidShardKey，实际为: %v", err)
+	}
+}
+
+// TestRangeStrategy 测试范围表策略
+func TestRangeStrategy(t *testing.T) {
+	s := RangeStrategy{Ranges: []ShardRange{
+		{Upper: 1000, Shard: 0},
+		{Upper: 5000, Shard: 1},
+		{Upper: 10000, Shard: 2},
+	}}
+
+	tests := []struct {
+		key  any
+		want int
+	}{
+		{0, 0},
+		{999, 0},
+		{1000, 1},
+		{uint64(9999), 2},
+	}
+	for _, tt := range tests {
+		if got, err := s.Shard(tt.key, 3); err != nil || got != tt.want {
+			t.Errorf("Shard(%v) = %d, %v, 期望 %d", tt.key, got, err, tt.want)
+		}
+	}
+
+	for _, key := range []any{10000, "user-1", -100, int64(-1)} {
+		if _, err := s.Shard(key, 3); !errors.Is(err, ErrInvalidShardKey) {
+			t.Errorf("Shard(%v) 应返回 ErrInvalidShardKey，实际为: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package mgorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrStatsUnsupported 当 Group 或 Manager 不是由 New、NewManager 创建时返回此错误，
+// 此时无法在不打开连接的情况下得知连接状态。
+var ErrStatsUnsupported = errors.New("mgorm: stats require a Group or Manager created by mgorm")
+
+// poolKey 标识 Manager 中的一个连接
+type poolKey struct {
+	group string // group 组名
+	name  string // name 连接名
+}
+
+// pools 记录已打开的连接
+type pools struct {
+	mu        sync.RWMutex
+	dbs       map[poolKey]*gorm.DB
+	draining  map[*gorm.DB]time.Duration // draining Rotate 替换下的连接及其最长排空时间
+	opening   map[uint64]*openCall       // opening 正在 registry 锁外打开的连接，key 为 DBConfig.regID
+	preopened map[uint64]*gorm.DB        // preopened 已在锁外打开、等待 opener 取走的连接，key 为 DBConfig.regID
+
+	// swapMu 保证 Rotate 时的“注销 - 重新注册”对 Get 是原子的
+	swapMu sync.RWMutex
+}
+
+// newPools 创建连接记录
+func newPools() *pools {
+	return &pools{
+		dbs:       make(map[poolKey]*gorm.DB),
+		draining:  make(map[*gorm.DB]time.Duration),
+		opening:   make(map[uint64]*openCall),
+		preopened: make(map[uint64]*gorm.DB),
+	}
+}
+
+// openCall 一次在 registry 锁外进行的打开，同一注册的并发 Get 共用其结果
+type openCall struct {
+	done chan struct{}
+	err  error
+}
+
+// errNotPreopened 由 pools.opener 返回，表示连接还没有在 registry 的锁外打开，group.Get 收到后先打开再重试
+var errNotPreopened = errors.New("mgorm: connection is not pre-opened")
+
+// opener 取走 preopen 在 registry 锁外打开的连接并记录为已打开，作为 registry 的 Opener 使用。
+// 与 closer 一样在 registry 的锁内调用，因此记录总是与注册状态一致：
+// 已注销的连接不会在 Get 返回之后又被记录为已打开。
+// 连接尚未打开时返回 errNotPreopened，不在锁内建立连接或等待重试。
+func (p *pools) opener(ctx context.Context, cfg DBConfig) (*gorm.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	db, ok := p.preopened[cfg.regID]
+	if !ok {
+		return nil, errNotPreopened
+	}
+	delete(p.preopened, cfg.regID)
+	p.dbs[poolKey{group: cfg.groupName, name: cfg.connName}] = db
+	return db, nil
+}
+
+// preopen 在 registry 的锁外调用 open 打开注册序号为 regID 的连接（包括重试的退避等待），留给 opener 取走。
+// 同一注册的并发调用只打开一次，其余调用等待其结果。
+func (p *pools) preopen(ctx context.Context, regID uint64, open func(context.Context) (*gorm.DB, error)) error {
+	p.mu.Lock()
+	if _, ok := p.preopened[regID]; ok {
+		p.mu.Unlock()
+		return nil
+	}
+	if call, ok := p.opening[regID]; ok {
+		p.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &openCall{done: make(chan struct{})}
+	p.opening[regID] = call
+	p.mu.Unlock()
+
+	db, err := open(ctx)
+
+	p.mu.Lock()
+	delete(p.opening, regID)
+	if err == nil {
+		p.preopened[regID] = db
+	}
+	call.err = err
+	p.mu.Unlock()
+	close(call.done)
+	return err
+}
+
+// discardPreopened 关闭没有被 opener 取走的连接，用于打开期间连接被注销或已由其他注册打开时
+func (p *pools) discardPreopened(regID uint64) {
+	p.mu.Lock()
+	db, ok := p.preopened[regID]
+	delete(p.preopened, regID)
+	p.mu.Unlock()
+	if ok {
+		_ = closer(context.Background(), db)
+	}
+}
+
+// get 返回已打开的连接
+func (p *pools) get(groupName, name string) (*gorm.DB, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	db, ok := p.dbs[poolKey{group: groupName, name: name}]
+	return db, ok
+}
+
+// closer 移除连接记录后关闭连接，作为 registry 的 Closer 使用。
+// Rotate 替换下的连接在后台排空后关闭。
+func (p *pools) closer(ctx context.Context, db *gorm.DB) error {
+	p.mu.Lock()
+	for key, v := range p.dbs {
+		if v == db {
+			delete(p.dbs, key)
+		}
+	}
+	drainTimeout, draining := p.draining[db]
+	delete(p.draining, db)
+	p.mu.Unlock()
+
+	if draining {
+		go drainAndClose(db, drainTimeout)
+		return nil
+	}
+	return closer(ctx, db)
+}
+
+// mgormGroup 返回 g 底层由 mgorm 创建的 *group。
+// g 可以是通过 Unwrap 方法暴露被包装 Group 的类型，如 EvictingGroup。
+func mgormGroup(g Group) (*group, bool) {
+	for {
+		switch v := g.(type) {
+		case *group:
+			return v, true
+		case interface{ Unwrap() Group }:
+			g = v.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// PoolStats 单个已注册连接的连接池统计
+type PoolStats struct {
+	Group  string      // Group 组名，New 创建的单组为空
+	Name   string      // Name 连接名
+	Opened bool        // Opened 连接是否已打开（已通过 Get 完成惰性初始化）
+	Stats  sql.DBStats // Stats 连接池统计，仅 Opened 为 true 时有效
+}
+
+// OpenedDB 返回 group 中已打开的连接，连接尚未打开或 group 不是由 mgorm 创建时返回 false。
+// 与 Get 不同，OpenedDB 不会触发惰性初始化。
+func OpenedDB(g Group, name string) (*gorm.DB, bool) {
+	mg, ok := mgormGroup(g)
+	if !ok {
+		return nil, false
+	}
+	return mg.pools.get(mg.name, name)
+}
+
+// GroupStats 返回 g 中每个已注册连接的统计信息，按连接名排序。
+// 只读取已打开连接的 sql.DBStats，不会打开尚未初始化的连接。
+func GroupStats(ctx context.Context, g Group) ([]PoolStats, error) {
+	mg, ok := mgormGroup(g)
+	if !ok {
+		return nil, ErrStatsUnsupported
+	}
+
+	names := g.List()
+	sort.Strings(names)
+
+	stats := make([]PoolStats, 0, len(names))
+	for _, name := range names {
+		s := PoolStats{Group: mg.name, Name: name}
+		if db, ok := mg.pools.get(mg.name, name); ok {
+			if sqlDB, err := db.DB(); err == nil {
+				s.Opened = true
+				s.Stats = sqlDB.Stats()
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// Stats 返回 m 中所有组的连接统计信息，按组名、连接名排序。
+// 只读取已打开连接的 sql.DBStats，不会打开尚未初始化的连接。
+func Stats(ctx context.Context, m Manager) ([]PoolStats, error) {
+	if _, ok := m.(*manager); !ok {
+		return nil, ErrStatsUnsupported
+	}
+
+	groupNames := m.ListGroupNames()
+	sort.Strings(groupNames)
+
+	var stats []PoolStats
+	for _, groupName := range groupNames {
+		g, err := m.Group(groupName)
+		if err != nil {
+			// 组在遍历过程中被关闭
+			continue
+		}
+		groupStats, err := GroupStats(ctx, g)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, groupStats...)
+	}
+	return stats, nil
+}
@@ -0,0 +1,139 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/qq1060656096/bizutil/registry"
+	"gorm.io/gorm"
+)
+
+// TestStats 测试 Manager 统计只包含已打开连接的 DBStats 且不会触发惰性初始化
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager()
+	defer manager.Close(ctx)
+
+	manager.AddGroup("master")
+	manager.AddGroup("slave")
+	master := manager.MustGroup("master")
+	slave := manager.MustGroup("slave")
+
+	for _, name := range []string{"db1", "db2"} {
+		master.Register(ctx, name, DBConfig{DriverType: "sqlite", DBName: ":memory:", MaxOpenConns: 7})
+	}
+	slave.Register(ctx, "db1", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+
+	// 只打开 master/db2
+	master.MustGet(ctx, "db2")
+
+	stats, err := Stats(ctx, manager)
+	if err != nil {
+		t.Fatalf("Stats() 失败: %v", err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("期望 3 条统计，实际 %d 条: %+v", len(stats), stats)
+	}
+
+	expected := []struct {
+		group, name string
+		opened      bool
+	}{
+		{"master", "db1", false},
+		{"master", "db2", true},
+		{"slave", "db1", false},
+	}
+	for i, e := range expected {
+		s := stats[i]
+		if s.Group != e.group || s.Name != e.name || s.Opened != e.opened {
+			t.Errorf("stats[%d] = %s/%s opened=%v, 期望 %s/%s opened=%v", i, s.Group, s.Name, s.Opened, e.group, e.name, e.opened)
+		}
+	}
+	if stats[1].Stats.MaxOpenConnections != 7 {
+		t.Errorf("MaxOpenConnections = %d, 期望 7", stats[1].Stats.MaxOpenConnections)
+	}
+
+	// 统计不会打开连接
+	if _, ok := OpenedDB(master, "db1"); ok {
+		t.Error("Stats 不应打开 master/db1")
+	}
+}
+
+// TestGroupStats_Unregister 测试注销或关闭后连接不再被视为已打开
+func TestGroupStats_Unregister(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	group.Register(ctx, "db", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+
+	db := group.MustGet(ctx, "db")
+	opened, ok := OpenedDB(group, "db")
+	if !ok || opened != db {
+		t.Fatal("Get 之后 OpenedDB 应返回同一连接")
+	}
+
+	// Ping 会临时打开并关闭连接，不应影响已打开连接的记录
+	if err := group.Ping(ctx, "db"); err != nil {
+		t.Fatalf("Ping() 失败: %v", err)
+	}
+	if _, ok := OpenedDB(group, "db"); !ok {
+		t.Error("Ping 之后连接仍应为已打开")
+	}
+
+	if err := group.Unregister(ctx, "db"); err != nil {
+		t.Fatalf("Unregister() 失败: %v", err)
+	}
+	if _, ok := OpenedDB(group, "db"); ok {
+		t.Error("Unregister 之后连接不应为已打开")
+	}
+
+	group.Register(ctx, "db", DBConfig{DriverType: "sqlite", DBName: ":memory:"})
+	group.MustGet(ctx, "db")
+	group.Close(ctx)
+	if _, ok := OpenedDB(group, "db"); ok {
+		t.Error("Close 之后连接不应为已打开")
+	}
+}
+
+// TestOpenedDB_ConcurrentUnregister 测试 Get 与注销、重新注册并发时，OpenedDB 不会返回已关闭的连接
+func TestOpenedDB_ConcurrentUnregister(t *testing.T) {
+	ctx := context.Background()
+	group := New()
+	defer group.Close(ctx)
+	cfg := DBConfig{DriverType: "sqlite", DBName: ":memory:"}
+
+	for i := 0; i < 200; i++ {
+		group.Register(ctx, "db", cfg)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = group.Get(ctx, "db")
+		}()
+		_ = group.Unregister(ctx, "db")
+		group.Register(ctx, "db", cfg)
+		wg.Wait()
+
+		if db, ok := OpenedDB(group, "db"); ok {
+			sqlDB, _ := db.DB()
+			if err := sqlDB.Ping(); err != nil {
+				t.Fatalf("OpenedDB 返回了已关闭的连接: %v", err)
+			}
+		}
+		_ = group.Unregister(ctx, "db")
+	}
+}
+
+// TestStats_Unsupported 测试非 mgorm 创建的 Group、Manager
+func TestStats_Unsupported(t *testing.T) {
+	ctx := context.Background()
+	rawGroup := registry.New[DBConfig, *gorm.DB](opener, closer)
+	if _, err := GroupStats(ctx, rawGroup); !errors.Is(err, ErrStatsUnsupported) {
+		t.Errorf("GroupStats() 错误 = %v, 期望 ErrStatsUnsupported", err)
+	}
+	rawManager := registry.NewManager[DBConfig, *gorm.DB](opener, closer)
+	if _, err := Stats(ctx, rawManager); !errors.Is(err, ErrStatsUnsupported) {
+		t.Errorf("Stats() 错误 = %v, 期望 ErrStatsUnsupported", err)
+	}
+}
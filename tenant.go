@@ -0,0 +1,291 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNoTenant 当 ctx 中没有通过 WithTenant 设置租户时返回此错误。
+var ErrNoTenant = errors.New("mgorm: no tenant in context")
+
+// tenantKey 是租户 ID 在 context 中的 key
+type tenantKey struct{}
+
+// WithTenant 返回携带租户 ID 的 ctx，供 TenantResolver.DB 使用
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext 返回 ctx 中的租户 ID
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// TenantLookup 返回租户对应的物理数据库名，如从配置中心或租户表中查询
+type TenantLookup func(ctx context.Context, tenantID string) (dbName string, err error)
+
+// TenantConfig 租户解析配置
+type TenantConfig struct {
+	// TemplateName 作为模板的连接名，各租户通过 RegisterToDB 复制该连接的配置
+	TemplateName string
+	// Lookup 查询租户对应的数据库名（必需）
+	Lookup TenantLookup
+	// NameFormat 租户连接名模板，使用 fmt 格式化租户 ID，如 "tenant_%s"；为空时使用 TemplateName + "_%s"
+	NameFormat string
+	// IdleTimeout 租户连接空闲超过该时间后通过 Unregister 关闭并注销，为 0 时不淘汰
+	IdleTimeout time.Duration
+}
+
+// TenantResolver 根据 ctx 中的租户 ID 返回该租户的数据库连接。
+//
+// 租户首次访问时通过 Lookup 查询数据库名，并以 TemplateName 为模板通过 RegisterToDB 注册连接；
+// 并发的首次访问只会查询与注册一次。设置了 IdleTimeout 时，后台定期注销空闲的租户连接，
+// 下次访问时重新查询与注册。
+type TenantResolver struct {
+	group      Group
+	cfg        TenantConfig
+	nameFormat string
+
+	mu      sync.Mutex
+	tenants map[string]*tenantEntry
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// tenantEntry 一个租户的注册状态
+type tenantEntry struct {
+	ready    chan struct{} // ready 注册完成后关闭
+	name     string        // name 租户的连接名
+	err      error         // err 查询或注册失败时的错误
+	lastUsed atomic.Int64  // lastUsed 最近一次访问的时间（UnixNano）
+	evicting bool          // evicting 租户连接正在被 EvictIdle 注销，ready 在注销完成后关闭，等待者之后重新注册
+}
+
+// NewTenantResolver 创建租户解析器。设置了 IdleTimeout 时启动后台淘汰协程，使用完毕后应调用 Close。
+func NewTenantResolver(group Group, cfg TenantConfig) (*TenantResolver, error) {
+	if cfg.Lookup == nil {
+		return nil, errors.New("mgorm: TenantConfig.Lookup is required")
+	}
+	if cfg.IdleTimeout < 0 {
+		return nil, fmt.Errorf("mgorm: TenantConfig.IdleTimeout must not be negative, got %s", cfg.IdleTimeout)
+	}
+
+	r := &TenantResolver{
+		group:      group,
+		cfg:        cfg,
+		nameFormat: cfg.NameFormat,
+		tenants:    make(map[string]*tenantEntry),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if r.nameFormat == "" {
+		r.nameFormat = cfg.TemplateName + "_%s"
+	}
+
+	if cfg.IdleTimeout > 0 {
+		go r.run()
+	} else {
+		close(r.done)
+	}
+	return r, nil
+}
+
+// DB 返回 ctx 中租户的数据库连接，ctx 中没有租户时返回 ErrNoTenant。
+func (r *TenantResolver) DB(ctx context.Context) (*gorm.DB, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	return r.TenantDB(ctx, tenantID)
+}
+
+// MustDB 返回 ctx 中租户的数据库连接，失败时 panic。
+func (r *TenantResolver) MustDB(ctx context.Context) *gorm.DB {
+	db, err := r.DB(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// TenantDB 返回指定租户的数据库连接，首次访问时查询并注册。
+func (r *TenantResolver) TenantDB(ctx context.Context, tenantID string) (*gorm.DB, error) {
+	name, err := r.register(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return r.group.Get(ctx, name)
+}
+
+// register 确保租户连接已注册并返回连接名，并发调用时只有一个调用者执行查询与注册
+func (r *TenantResolver) register(ctx context.Context, tenantID string) (string, error) {
+	var entry *tenantEntry
+	for {
+		r.mu.Lock()
+		e, ok := r.tenants[tenantID]
+		if !ok {
+			entry = &tenantEntry{ready: make(chan struct{})}
+			r.tenants[tenantID] = entry
+			r.mu.Unlock()
+			break
+		}
+		r.mu.Unlock()
+		e.lastUsed.Store(time.Now().UnixNano())
+
+		select {
+		case <-e.ready:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if e.evicting {
+			// 注销完成，重新注册
+			continue
+		}
+		return e.name, e.err
+	}
+	entry.lastUsed.Store(time.Now().UnixNano())
+
+	entry.name, entry.err = r.registerTenant(ctx, tenantID)
+	close(entry.ready)
+	if entry.err != nil {
+		// 失败的结果不缓存，下次访问时重试
+		r.mu.Lock()
+		if r.tenants[tenantID] == entry {
+			delete(r.tenants, tenantID)
+		}
+		r.mu.Unlock()
+	}
+	return entry.name, entry.err
+}
+
+// registerTenant 查询租户的数据库名并通过 RegisterToDB 注册连接
+func (r *TenantResolver) registerTenant(ctx context.Context, tenantID string) (string, error) {
+	dbName, err := r.cfg.Lookup(ctx, tenantID)
+	if err != nil {
+		return "", fmt.Errorf("mgorm: lookup tenant %q: %w", tenantID, err)
+	}
+	if dbName == "" {
+		return "", fmt.Errorf("mgorm: lookup tenant %q: empty database name", tenantID)
+	}
+
+	name := fmt.Sprintf(r.nameFormat, tenantID)
+	if _, err := RegisterToDB(ctx, r.group, r.cfg.TemplateName, name, dbName); err != nil {
+		return "", fmt.Errorf("mgorm: register tenant %q: %w", tenantID, err)
+	}
+	return name, nil
+}
+
+// Tenants 返回已注册的租户 ID，按字典序排列
+func (r *TenantResolver) Tenants() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sortedKeys(r.tenants)
+}
+
+// EvictIdle 注销空闲超过 IdleTimeout 的租户连接，返回被注销的租户数。IdleTimeout 为 0 时不做任何处理。
+//
+// 注销会关闭租户的连接，调用者不应在空闲超过 IdleTimeout 后继续使用之前取得的 *gorm.DB。
+// 关闭连接池会等待进行中的查询结束，因此注销在锁外进行，不阻塞其他租户的访问；
+// 注销期间同一租户的访问等待注销完成后重新注册。
+func (r *TenantResolver) EvictIdle(ctx context.Context) int {
+	if r.cfg.IdleTimeout <= 0 {
+		return 0
+	}
+	deadline := time.Now().Add(-r.cfg.IdleTimeout).UnixNano()
+
+	type eviction struct {
+		tenantID    string
+		name        string
+		placeholder *tenantEntry
+	}
+	var evictions []eviction
+
+	r.mu.Lock()
+	for tenantID, entry := range r.tenants {
+		if entry.evicting {
+			continue
+		}
+		select {
+		case <-entry.ready:
+		default:
+			// 正在注册
+			continue
+		}
+		if entry.lastUsed.Load() > deadline {
+			continue
+		}
+		// 以正在注销的占位项替换，避免同一租户在注销过程中被重新注册后又被注销
+		placeholder := &tenantEntry{ready: make(chan struct{}), evicting: true}
+		r.tenants[tenantID] = placeholder
+		evictions = append(evictions, eviction{tenantID: tenantID, name: entry.name, placeholder: placeholder})
+	}
+	r.mu.Unlock()
+
+	for _, e := range evictions {
+		_ = r.group.Unregister(ctx, e.name)
+		r.mu.Lock()
+		if r.tenants[e.tenantID] == e.placeholder {
+			delete(r.tenants, e.tenantID)
+		}
+		r.mu.Unlock()
+		close(e.placeholder.ready)
+	}
+	return len(evictions)
+}
+
+// run 定期淘汰空闲的租户连接
+func (r *TenantResolver) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.evictInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.EvictIdle(context.Background())
+		}
+	}
+}
+
+// evictInterval 返回后台淘汰的检查间隔
+func (r *TenantResolver) evictInterval() time.Duration {
+	return max(r.cfg.IdleTimeout/2, time.Millisecond)
+}
+
+// Close 停止后台淘汰并注销所有租户连接，返回注销时遇到的错误。
+func (r *TenantResolver) Close(ctx context.Context) error {
+	r.closeOnce.Do(func() { close(r.stop) })
+	<-r.done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for _, tenantID := range sortedKeys(r.tenants) {
+		entry := r.tenants[tenantID]
+		if entry.evicting {
+			// 由 EvictIdle 注销
+			delete(r.tenants, tenantID)
+			continue
+		}
+		<-entry.ready
+		if entry.err == nil {
+			if err := r.group.Unregister(ctx, entry.name); err != nil {
+				errs = append(errs, fmt.Errorf("tenant %s: %w", tenantID, err))
+			}
+		}
+		delete(r.tenants, tenantID)
+	}
+	return errors.Join(errs...)
+}
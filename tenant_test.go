@@ -0,0 +1,235 @@
+package mgorm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// newTenantGroup 创建包含模板连接 tpl 的 Group，租户数据库为 dir 下的 SQLite 文件
+func newTenantGroup(t *testing.T) (Group, string) {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+	group := New()
+	t.Cleanup(func() { group.Close(ctx) })
+	group.Register(ctx, "tpl", DBConfig{DriverType: "sqlite", DBName: filepath.Join(dir, "tpl.db")})
+	return group, dir
+}
+
+// TestTenantResolver_DB 测试并发首次访问只查询与注册一次
+func TestTenantResolver_DB(t *testing.T) {
+	group, dir := newTenantGroup(t)
+	var lookups int32
+	resolver, err := NewTenantResolver(group, TenantConfig{
+		TemplateName: "tpl",
+		NameFormat:   "tenant_%s",
+		Lookup: func(ctx context.Context, tenantID string) (string, error) {
+			atomic.AddInt32(&lookups, 1)
+			time.Sleep(10 * time.Millisecond)
+			return filepath.Join(dir, tenantID+".db"), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewTenantResolver() 失败: %v", err)
+	}
+	defer resolver.Close(context.Background())
+
+	ctx := WithTenant(context.Background(), "acme")
+	dbs := make([]*gorm.DB, 20)
+	var wg sync.WaitGroup
+	for i := range dbs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db, err := resolver.DB(ctx)
+			if err != nil {
+				t.Errorf("DB() 失败: %v", err)
+			}
+			dbs[i] = db
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&lookups); n != 1 {
+		t.Errorf("Lookup 调用次数 = %d, 期望 1", n)
+	}
+	for i, db := range dbs {
+		if db != dbs[0] {
+			t.Fatalf("第 %d 次返回的连接与第一次不同", i)
+		}
+	}
+	if cfg := group.MustConfig(ctx, "tenant_acme"); cfg.DBName != filepath.Join(dir, "acme.db") {
+		t.Errorf("tenant_acme 的 DBName = %q", cfg.DBName)
+	}
+
+	if _, err := resolver.DB(context.Background()); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("没有租户时应返回 ErrNoTenant，实际为: %v", err)
+	}
+}
+
+// TestTenantResolver_LookupError 测试查询失败不会被缓存
+func TestTenantResolver_LookupError(t *testing.T) {
+	group, dir := newTenantGroup(t)
+	lookupErr := errors.New("tenant not found")
+	fail := true
+	resolver, _ := NewTenantResolver(group, TenantConfig{
+		TemplateName: "tpl",
+		Lookup: func(ctx context.Context, tenantID string) (string, error) {
+			if fail {
+				return "", lookupErr
+			}
+			return filepath.Join(dir, tenantID+".db"), nil
+		},
+	})
+	defer resolver.Close(context.Background())
+
+	ctx := WithTenant(context.Background(), "acme")
+	if _, err := resolver.DB(ctx); !errors.Is(err, lookupErr) {
+		t.Fatalf("应返回 Lookup 的错误，实际为: %v", err)
+	}
+	fail = false
+	if _, err := resolver.DB(ctx); err != nil {
+		t.Fatalf("Lookup 恢复后应成功: %v", err)
+	}
+	if _, err := group.Config(ctx, "tpl_acme"); err != nil {
+		t.Errorf("默认连接名应为 tpl_acme: %v", err)
+	}
+}
+
+// TestTenantResolver_EvictIdle 测试空闲租户被注销，再次访问时重新注册
+func TestTenantResolver_EvictIdle(t *testing.T) {
+	group, dir := newTenantGroup(t)
+	var lookups int32
+	resolver, _ := NewTenantResolver(group, TenantConfig{
+		TemplateName: "tpl",
+		IdleTimeout:  20 * time.Millisecond,
+		Lookup: func(ctx context.Context, tenantID string) (string, error) {
+			atomic.AddInt32(&lookups, 1)
+			return filepath.Join(dir, tenantID+".db"), nil
+		},
+	})
+	defer resolver.Close(context.Background())
+
+	ctx := WithTenant(context.Background(), "acme")
+	resolver.MustDB(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(resolver.Tenants()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("空闲租户应被后台淘汰")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := group.Config(ctx, "tpl_acme"); err == nil {
+		t.Error("淘汰后租户连接应被注销")
+	}
+
+	resolver.MustDB(ctx)
+	if n := atomic.LoadInt32(&lookups); n != 2 {
+		t.Errorf("淘汰后再次访问应重新查询，Lookup 调用次数 = %d", n)
+	}
+}
+
+// blockingUnregisterGroup 注销连接时等待 release 关闭的 Group，用于模拟关闭连接池时等待进行中的查询
+type blockingUnregisterGroup struct {
+	Group
+	unregistering chan struct{}
+	release       chan struct{}
+}
+
+// Unregister 通知 unregistering 后等待 release 关闭再注销
+func (g blockingUnregisterGroup) Unregister(ctx context.Context, name string) error {
+	g.unregistering <- struct{}{}
+	<-g.release
+	return g.Group.Unregister(ctx, name)
+}
+
+// TestTenantResolver_EvictIdleUnlocked 测试注销空闲租户时不阻塞其他租户的访问，
+// 同一租户的访问等待注销完成后重新注册
+func TestTenantResolver_EvictIdleUnlocked(t *testing.T) {
+	inner, dir := newTenantGroup(t)
+	group := blockingUnregisterGroup{Group: inner, unregistering: make(chan struct{}, 1), release: make(chan struct{})}
+	resolver, _ := NewTenantResolver(group, TenantConfig{
+		TemplateName: "tpl",
+		IdleTimeout:  time.Hour,
+		Lookup: func(ctx context.Context, tenantID string) (string, error) {
+			return filepath.Join(dir, tenantID+".db"), nil
+		},
+	})
+	ctx := context.Background()
+	if _, err := resolver.TenantDB(ctx, "acme"); err != nil {
+		t.Fatal(err)
+	}
+	resolver.mu.Lock()
+	resolver.tenants["acme"].lastUsed.Store(0)
+	resolver.mu.Unlock()
+
+	evicted := make(chan int, 1)
+	go func() { evicted <- resolver.EvictIdle(ctx) }()
+	<-group.unregistering
+
+	// 其他租户不等待注销
+	if _, err := resolver.TenantDB(ctx, "other"); err != nil {
+		t.Fatalf("注销期间访问其他租户失败: %v", err)
+	}
+	// 同一租户等待注销完成
+	acme := make(chan error, 1)
+	go func() {
+		_, err := resolver.TenantDB(ctx, "acme")
+		acme <- err
+	}()
+	select {
+	case err := <-acme:
+		t.Fatalf("注销完成前同一租户的访问应等待: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(group.release)
+	if n := <-evicted; n != 1 {
+		t.Errorf("EvictIdle() = %d, 期望 1", n)
+	}
+	if err := <-acme; err != nil {
+		t.Fatalf("注销完成后应重新注册: %v", err)
+	}
+	if _, err := inner.Config(ctx, "tpl_acme"); err != nil {
+		t.Error("重新注册的租户连接不应被注销")
+	}
+	go func() {
+		for range group.unregistering {
+		}
+	}()
+	resolver.Close(ctx)
+	close(group.unregistering)
+}
+
+// TestTenantResolver_Close 测试 Close 注销所有租户连接
+func TestTenantResolver_Close(t *testing.T) {
+	group, dir := newTenantGroup(t)
+	resolver, _ := NewTenantResolver(group, TenantConfig{
+		TemplateName: "tpl",
+		Lookup: func(ctx context.Context, tenantID string) (string, error) {
+			return filepath.Join(dir, tenantID+".db"), nil
+		},
+	})
+
+	ctx := context.Background()
+	resolver.TenantDB(ctx, "a")
+	resolver.TenantDB(ctx, "b")
+	if err := resolver.Close(ctx); err != nil {
+		t.Fatalf("Close() 失败: %v", err)
+	}
+	if names := group.List(); len(names) != 1 || names[0] != "tpl" {
+		t.Errorf("Close 后只应保留模板连接，实际为 %v", names)
+	}
+
+	if _, err := NewTenantResolver(group, TenantConfig{TemplateName: "tpl"}); err == nil {
+		t.Error("缺少 Lookup 时应返回错误")
+	}
+}
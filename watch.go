@@ -0,0 +1,199 @@
+package mgorm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval 是 WatchOptions.Interval 为 0 时检查配置文件的间隔
+const DefaultWatchInterval = 5 * time.Second
+
+// WatchOptions WatchConfigFile 的选项
+type WatchOptions struct {
+	// Interval 检查配置文件的间隔，为 0 时使用 DefaultWatchInterval
+	Interval time.Duration
+	// DrainTimeout 被替换或注销的连接池的最长排空时间，为 0 时使用 DefaultRotateDrainTimeout
+	DrainTimeout time.Duration
+	// OnEvent 每个连接变更后的回调（可选），在应用配置的协程中依次调用，不应阻塞
+	OnEvent func(ReloadEvent)
+	// OnError 读取、解码或应用配置失败时的回调（可选），为 nil 时记录到 slog.Default()
+	OnError func(error)
+}
+
+// ConfigWatcher 监听配置文件并把变化应用到 Manager，参见 WatchConfigFile
+type ConfigWatcher struct {
+	manager Manager
+	path    string
+	format  ConfigFormat
+	opts    WatchOptions
+
+	mu      sync.Mutex
+	applied ManagerConfig     // applied 上次应用的配置
+	sum     [sha256.Size]byte // sum 上次读取的文件内容摘要
+	dirty   bool              // dirty 上次应用有组回滚，下次检查时即使文件未变化也重试
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// WatchConfigFile 每隔 Interval 检查 path 的内容，变化时通过 ApplyManagerConfig 把差异应用到 m，
+// 通常与 LoadManagerFromFile 一起使用：
+//
+//	manager, err := mgorm.LoadManagerFromFile(ctx, "db.yml")
+//	watcher, err := mgorm.WatchConfigFile(ctx, manager, "db.yml", mgorm.WatchOptions{OnEvent: onEvent})
+//	defer watcher.Close()
+//
+// 启动时把文件中的配置与 m 中当前注册的配置比较：未注册的连接注册，配置不同的连接替换，不注销任何连接；
+// 文件无法读取或解码时返回错误。
+// 通过比较文件内容判断变化，因此也适用于 Kubernetes ConfigMap 这类替换符号链接更新的文件。
+// 文件解码失败时保留当前状态，直到文件再次变化；有组回滚时，即使文件没有变化，下次检查也会重试。
+// ctx 结束或调用 Close 后停止监听。
+func WatchConfigFile(ctx context.Context, m Manager, path string, opts WatchOptions) (*ConfigWatcher, error) {
+	if opts.Interval < 0 || opts.DrainTimeout < 0 {
+		return nil, errors.New("mgorm: WatchOptions.Interval and DrainTimeout must not be negative")
+	}
+	if opts.Interval == 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &ConfigWatcher{
+		manager: m,
+		path:    path,
+		format:  format,
+		opts:    opts,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	cfgs, sum, err := w.read()
+	if err != nil {
+		return nil, err
+	}
+	// 没有上次应用的配置，applied 为空时 ApplyManagerConfig 与当前注册的配置比较
+	if err := w.apply(ctx, cfgs, sum); err != nil {
+		w.reportError(err)
+	}
+
+	go w.run(ctx)
+	return w, nil
+}
+
+// Reload 立即检查配置文件，内容变化或上次应用有组回滚时应用，返回读取、解码或应用的错误
+func (w *ConfigWatcher) Reload(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return fmt.Errorf("mgorm: read config file: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if sum == w.sum && !w.dirty {
+		return nil
+	}
+
+	cfgs, err := DecodeManagerConfig(bytes.NewReader(data), w.format)
+	if err != nil {
+		// 同一份错误的文件只报告一次
+		w.sum = sum
+		return err
+	}
+	return w.apply(ctx, cfgs, sum)
+}
+
+// Applied 返回上次应用的配置的副本，回滚的组为回滚后的配置
+func (w *ConfigWatcher) Applied() ManagerConfig {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return cloneManagerConfig(w.applied)
+}
+
+// Close 停止监听并等待正在进行的检查结束，不会关闭 Manager
+func (w *ConfigWatcher) Close() {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+}
+
+// cloneManagerConfig 深拷贝 cfgs，修改副本不影响 cfgs
+func cloneManagerConfig(cfgs ManagerConfig) ManagerConfig {
+	if cfgs == nil {
+		return nil
+	}
+	clone := make(ManagerConfig, len(cfgs))
+	for groupName, group := range cfgs {
+		clone[groupName] = make(map[string]DBConfig, len(group))
+		for name, cfg := range group {
+			cfg.Replicas = slices.Clone(cfg.Replicas)
+			clone[groupName][name] = cfg
+		}
+	}
+	return clone
+}
+
+// read 读取并解码配置文件
+func (w *ConfigWatcher) read() (ManagerConfig, [sha256.Size]byte, error) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, [sha256.Size]byte{}, fmt.Errorf("mgorm: read config file: %w", err)
+	}
+	cfgs, err := DecodeManagerConfig(bytes.NewReader(data), w.format)
+	if err != nil {
+		return nil, [sha256.Size]byte{}, err
+	}
+	return cfgs, sha256.Sum256(data), nil
+}
+
+// apply 应用 cfgs 并通知事件，调用方需持有 w.mu（启动时除外）
+func (w *ConfigWatcher) apply(ctx context.Context, cfgs ManagerConfig, sum [sha256.Size]byte) error {
+	report, err := ApplyManagerConfig(ctx, w.manager, w.applied, cfgs, ApplyOptions{DrainTimeout: w.opts.DrainTimeout})
+	w.applied = report.Applied
+	w.sum = sum
+	w.dirty = err != nil
+	if w.opts.OnEvent != nil {
+		for _, event := range report.Events {
+			w.opts.OnEvent(event)
+		}
+	}
+	return err
+}
+
+// run 定期检查配置文件，直到 ctx 结束或 Close
+func (w *ConfigWatcher) run(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := w.Reload(ctx); err != nil {
+				w.reportError(err)
+			}
+		}
+	}
+}
+
+// reportError 通过 OnError 报告错误，未设置时记录到 slog.Default()
+func (w *ConfigWatcher) reportError(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+		return
+	}
+	slog.Default().Warn("mgorm: reload config failed", slog.String("path", w.path), slog.Any("error", err))
+}
@@ -0,0 +1,260 @@
+package mgorm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeWatchConfig 把 names 作为 business 组的 SQLite 连接写入 YAML 配置文件
+func writeWatchConfig(t *testing.T, path, dir string, names ...string) {
+	t.Helper()
+	content := "business:\n"
+	for _, name := range names {
+		content += fmt.Sprintf("  %s:\n    driver_type: sqlite\n    db_name: %q\n", name, filepath.Join(dir, name+".db"))
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// groupNames 返回组中按字典序排列的连接名
+func groupNames(group Group) []string {
+	names := group.List()
+	sort.Strings(names)
+	return names
+}
+
+// eventRecorder 记录 OnEvent 收到的事件
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *eventRecorder) record(event ReloadEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, string(event.Type)+" "+event.Group+"/"+event.Name)
+}
+
+func (r *eventRecorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+// TestConfigWatcher_Reload 测试文件变化时应用差异，内容未变化与解码失败时保留当前状态
+func TestConfigWatcher_Reload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.yml")
+	writeWatchConfig(t, path, dir, "a", "b")
+
+	manager, err := LoadManagerFromFile(ctx, path)
+	if err != nil {
+		t.Fatalf("LoadManagerFromFile() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	var recorder eventRecorder
+	var errs []error
+	watcher, err := WatchConfigFile(ctx, manager, path, WatchOptions{
+		Interval: time.Hour,
+		OnEvent:  recorder.record,
+		OnError:  func(err error) { errs = append(errs, err) },
+	})
+	if err != nil {
+		t.Fatalf("WatchConfigFile() 失败: %v", err)
+	}
+	defer watcher.Close()
+	if events := recorder.take(); len(events) != 0 {
+		t.Errorf("配置与 Manager 一致时启动不应有变更: %q", events)
+	}
+
+	writeWatchConfig(t, path, dir, "b", "c")
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Reload() 失败: %v", err)
+	}
+	if events := fmt.Sprint(recorder.take()); events != "[added business/c removed business/a]" {
+		t.Errorf("events = %s", events)
+	}
+	if names := fmt.Sprint(groupNames(manager.MustGroup("business"))); names != "[b c]" {
+		t.Errorf("List() = %s", names)
+	}
+
+	// 内容未变化时不重复应用
+	if err := watcher.Reload(ctx); err != nil || len(recorder.take()) != 0 {
+		t.Errorf("内容未变化时不应有变更: %v", err)
+	}
+
+	// 解码失败时保留当前状态，同一份错误的文件只报告一次
+	if err := os.WriteFile(path, []byte("business:\n  b:\n    unknown_key: 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(ctx); err == nil {
+		t.Error("解码失败时应返回错误")
+	}
+	if err := watcher.Reload(ctx); err != nil {
+		t.Errorf("同一份错误的文件不应重复报告: %v", err)
+	}
+	if names := fmt.Sprint(groupNames(manager.MustGroup("business"))); names != "[b c]" {
+		t.Errorf("解码失败时应保留当前状态: %s", names)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Reload 的错误不应通过 OnError 报告: %v", errs)
+	}
+}
+
+// TestConfigWatcher_Retry 测试组回滚后即使文件没有变化也会在下次检查时重试
+func TestConfigWatcher_Retry(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.yml")
+	writeWatchConfig(t, path, dir, "a")
+
+	manager, err := LoadManagerFromFile(ctx, path)
+	if err != nil {
+		t.Fatalf("LoadManagerFromFile() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+	watcher, err := WatchConfigFile(ctx, manager, path, WatchOptions{Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("WatchConfigFile() 失败: %v", err)
+	}
+	defer watcher.Close()
+	// 只有已打开的连接替换前会确认新配置能够连接
+	manager.MustGroup("business").MustGet(ctx, "a")
+
+	// a 指向尚不存在的目录，无法连接
+	missing := filepath.Join(dir, "missing")
+	writeWatchConfig(t, path, missing, "a")
+	if err := watcher.Reload(ctx); err == nil {
+		t.Fatal("新配置无法连接时应返回错误")
+	}
+	if got := watcher.Applied()["business"]["a"].DBName; got != filepath.Join(dir, "a.db") {
+		t.Errorf("回滚后 Applied 应为原配置，实际为 %s", got)
+	}
+
+	if err := os.Mkdir(missing, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("重试应成功: %v", err)
+	}
+	if cfg := manager.MustGroup("business").MustConfig(ctx, "a"); cfg.DBName != filepath.Join(missing, "a.db") {
+		t.Errorf("重试后应使用新配置，实际为 %s", cfg.DBName)
+	}
+}
+
+// TestWatchConfigFile_Startup 测试启动时与 Manager 中当前注册的配置比较，以及 Applied 返回副本
+func TestWatchConfigFile_Startup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.yml")
+	other := filepath.Join(dir, "other")
+	if err := os.Mkdir(other, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Manager 中 a 的配置与文件不同，b 未注册，extra 不在文件中
+	manager, err := LoadManager(ctx, ManagerConfig{"business": {
+		"a":     reloadTestConfig(other, "a"),
+		"extra": reloadTestConfig(dir, "extra"),
+	}})
+	if err != nil {
+		t.Fatalf("LoadManager() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+	writeWatchConfig(t, path, dir, "a", "b")
+
+	var recorder eventRecorder
+	watcher, err := WatchConfigFile(ctx, manager, path, WatchOptions{Interval: time.Hour, OnEvent: recorder.record})
+	if err != nil {
+		t.Fatalf("WatchConfigFile() 失败: %v", err)
+	}
+	defer watcher.Close()
+	if events := fmt.Sprint(recorder.take()); events != "[replaced business/a added business/b]" {
+		t.Errorf("启动时的 events = %s", events)
+	}
+	business := manager.MustGroup("business")
+	if cfg := business.MustConfig(ctx, "a"); cfg.DBName != filepath.Join(dir, "a.db") {
+		t.Errorf("启动时应替换配置不同的连接，实际为 %s", cfg.DBName)
+	}
+	if names := fmt.Sprint(groupNames(business)); names != "[a b extra]" {
+		t.Errorf("启动时不应注销连接: %s", names)
+	}
+
+	applied := watcher.Applied()
+	delete(applied["business"], "a")
+	applied["public"] = map[string]DBConfig{}
+	if again := watcher.Applied(); len(again) != 1 || len(again["business"]) != 2 {
+		t.Errorf("修改 Applied 的返回值不应影响 watcher: %v", again)
+	}
+}
+
+// TestConfigWatcher_Poll 测试监听协程定期检查文件，以及 Close 停止监听
+func TestConfigWatcher_Poll(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.yml")
+	writeWatchConfig(t, path, dir, "a")
+
+	manager, err := LoadManagerFromFile(ctx, path)
+	if err != nil {
+		t.Fatalf("LoadManagerFromFile() 失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	var recorder eventRecorder
+	watcher, err := WatchConfigFile(ctx, manager, path, WatchOptions{Interval: 10 * time.Millisecond, OnEvent: recorder.record})
+	if err != nil {
+		t.Fatalf("WatchConfigFile() 失败: %v", err)
+	}
+
+	writeWatchConfig(t, path, dir, "a", "b")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(manager.MustGroup("business").List()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("监听协程应应用文件的变化")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	watcher.Close()
+	watcher.Close()
+
+	writeWatchConfig(t, path, dir, "a")
+	time.Sleep(50 * time.Millisecond)
+	if len(manager.MustGroup("business").List()) != 2 {
+		t.Error("Close 后不应再应用文件的变化")
+	}
+	if events := recorder.take(); len(events) != 1 || events[0] != "added business/b" {
+		t.Errorf("events = %q", events)
+	}
+}
+
+// TestWatchConfigFile_Errors 测试文件无法读取或解码、选项无效时返回错误
+func TestWatchConfigFile_Errors(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager()
+	defer manager.Close(ctx)
+	dir := t.TempDir()
+
+	if _, err := WatchConfigFile(ctx, manager, filepath.Join(dir, "missing.yml"), WatchOptions{}); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+	if _, err := WatchConfigFile(ctx, manager, filepath.Join(dir, "db.ini"), WatchOptions{}); err == nil {
+		t.Error("不支持的格式应返回错误")
+	}
+	path := filepath.Join(dir, "db.yml")
+	writeWatchConfig(t, path, dir, "a")
+	if _, err := WatchConfigFile(ctx, manager, path, WatchOptions{Interval: -1}); err == nil {
+		t.Error("Interval 为负数时应返回错误")
+	}
+}